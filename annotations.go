@@ -0,0 +1,85 @@
+package inspector
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Entfernt Leerzeichen, leere Einträge und doppelte Tags
+func normalizeTags(tags []string) []string {
+	result := []string{}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !containsString(result, tag) {
+			result = append(result, tag)
+		}
+	}
+	return result
+}
+
+// Ändert Tags, Notiz und Markierung einer Anfrage. Nur die übergebenen Felder werden ersetzt,
+// z. B. {"tags": ["retry"], "note": "zweite Zustellung", "starred": true}.
+// Die geänderte Anfrage wird gespeichert und als "request.updated" an die SSE-Clients gesendet.
+func updateRequestAnnotations(c *gin.Context) {
+	var body struct {
+		Tags    *[]string `json:"tags"`
+		Note    *string   `json:"note"`
+		Starred *bool     `json:"starred"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "Ungültiger Body")
+		return
+	}
+
+	req, ok := findRequest(c.Param("id"))
+	if !ok {
+		c.String(http.StatusNotFound, "Anfrage nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, req.Bin, true) {
+		return
+	}
+
+	// Die Anfrage kann inzwischen durch die Aufbewahrungsregeln entfernt worden sein
+	found := false
+	requestsMu.Lock()
+	for i := range requests {
+		if requests[i].ID != req.ID {
+			continue
+		}
+		if body.Tags != nil {
+			requests[i].Tags = normalizeTags(*body.Tags)
+		}
+		if body.Note != nil {
+			requests[i].Note = *body.Note
+		}
+		if body.Starred != nil {
+			requests[i].Starred = *body.Starred
+		}
+		req = requests[i]
+		found = true
+		// Noch unter der Sperre speichern: Löschen und Aufbewahrung entfernen die Anfrage zuerst aus der Slice,
+		// danach ihre Dateien, eine spätere Änderung kann die Datei also nicht wieder anlegen
+		saveToFile(req)
+		break
+	}
+	requestsMu.Unlock()
+	if !found {
+		c.String(http.StatusNotFound, "Anfrage nicht gefunden")
+		return
+	}
+
+	broadcastRequestEvent(eventRequestUpdated, req, req)
+	c.JSON(http.StatusOK, req)
+}
@@ -0,0 +1,333 @@
+package inspector
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Rollen, die ein API-Token haben kann.
+// Admin-Tokens dürfen alles, Bin-Tokens nur lesend bzw. lesend und schreibend auf ihren Bin zugreifen.
+const (
+	roleAdmin     = "admin"
+	roleReadOnly  = "read-only"
+	roleReadWrite = "read-write"
+)
+
+// Ein API-Token für die Management-API.
+// Gespeichert wird nur der SHA-256-Hash, der Klartext wird beim Anlegen einmalig ausgegeben.
+type Token struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	Bin       string    `json:"bin,omitempty"`
+	Hash      string    `json:"hash,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Alle bekannten Tokens, nach Hash indiziert
+var (
+	tokens   = make(map[string]Token)
+	tokensMu sync.RWMutex
+)
+
+// Legt das Verzeichnis ./tokens an, in dem jedes Token als eigene Datei gespeichert wird
+func createTokensDirectory() error {
+	return os.MkdirAll(dataPath("tokens"), 0700)
+}
+
+// Lese alle Tokens aus dem Verzeichnis ./tokens.
+// Ist admin_token konfiguriert, wird dieses Token zusätzlich als Admin-Token übernommen.
+// Gibt es danach noch kein Admin-Token, wird eines erzeugt und einmalig im Log ausgegeben.
+func restoreTokens() {
+	entries, err := os.ReadDir(dataPath("tokens"))
+	if err != nil {
+		log.Fatal("Fehler beim Lesen der Tokens:", err)
+	}
+
+	tokensMu.Lock()
+	defer tokensMu.Unlock()
+
+	for _, entry := range entries {
+		data, err := os.ReadFile(dataPath("tokens", entry.Name()))
+		if err != nil {
+			log.Println("Fehler beim Lesen der Datei:", err)
+			continue
+		}
+
+		var t Token
+		if err := json.Unmarshal(data, &t); err != nil {
+			log.Println("Fehler beim Entmarshalling des Tokens:", err)
+			continue
+		}
+		tokens[t.Hash] = t
+	}
+
+	// Das Token aus der Konfiguration wird nur im Speicher gehalten
+	if secret := config.AdminToken; secret != "" {
+		t := Token{ID: "config", Name: "admin_token", Role: roleAdmin, Hash: hashToken(secret), CreatedAt: time.Now()}
+		tokens[t.Hash] = t
+	}
+
+	for _, t := range tokens {
+		if t.Role == roleAdmin {
+			return
+		}
+	}
+
+	secret := generateTokenSecret()
+	t := Token{ID: uuid.New().String(), Name: "bootstrap", Role: roleAdmin, Hash: hashToken(secret), CreatedAt: time.Now()}
+	tokens[t.Hash] = t
+	saveTokenToFile(t)
+	log.Println("Kein Admin-Token vorhanden, neues Admin-Token erzeugt:", secret)
+}
+
+// Speichere ein Token (nur mit Hash) in die Datei ./tokens/{id}.json
+func saveTokenToFile(t Token) {
+	data, err := json.MarshalIndent(t, "", "    ")
+	if err != nil {
+		log.Println("Fehler beim Marshalling des Tokens:", err)
+		return
+	}
+
+	if err := os.WriteFile(dataPath("tokens", t.ID+".json"), data, 0600); err != nil {
+		log.Println("Fehler beim Schreiben der Datei:", err)
+	}
+}
+
+// Erzeugt ein zufälliges Token-Geheimnis aus 32 Bytes
+func generateTokenSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatal("Fehler beim Erzeugen des Tokens:", err)
+	}
+	return "hri_" + hex.EncodeToString(b)
+}
+
+// Berechnet den SHA-256-Hash eines Token-Geheimnisses als Hex-String
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Setzt die CORS-Header der Management-API und beantwortet Preflight-Anfragen direkt,
+// damit Browser das Token im Authorization-Header mitschicken können
+func corsHeaders(c *gin.Context) {
+	c.Header("Access-Control-Allow-Origin", "*")
+	c.Header("Access-Control-Allow-Headers", "Authorization, Content-Type, Last-Event-ID")
+	c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+
+	if c.Request.Method == http.MethodOptions {
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// Access-Log im Format von gin.Logger, in dem der Query-Parameter "token" maskiert ist.
+// /sse und /ws akzeptieren das Token in der URL, es soll aber nicht im Log stehen.
+func accessLog() gin.HandlerFunc {
+	return gin.LoggerWithFormatter(func(p gin.LogFormatterParams) string {
+		var statusColor, methodColor, resetColor string
+		if p.IsOutputColor() {
+			statusColor, methodColor, resetColor = p.StatusCodeColor(), p.MethodColor(), p.ResetColor()
+		}
+		if p.Latency > time.Minute {
+			p.Latency = p.Latency.Truncate(time.Second)
+		}
+		return fmt.Sprintf("[GIN] %v |%s %3d %s| %13v | %15s |%s %-7s %s %#v\n%s",
+			p.TimeStamp.Format("2006/01/02 - 15:04:05"),
+			statusColor, p.StatusCode, resetColor,
+			p.Latency,
+			p.ClientIP,
+			methodColor, p.Method, resetColor,
+			maskTokenQuery(p.Path),
+			p.ErrorMessage,
+		)
+	})
+}
+
+// Ersetzt den Wert des Query-Parameters "token" in einer URI durch maskedSecret
+func maskTokenQuery(uri string) string {
+	p, rawQuery, ok := strings.Cut(uri, "?")
+	if !ok {
+		return uri
+	}
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		key, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err == nil && name == "token" {
+			params[i] = key + "=" + maskedSecret
+		}
+	}
+	return p + "?" + strings.Join(params, "&")
+}
+
+// Middleware, die das Token aus dem Header "Authorization: Bearer ..." prüft.
+// Mit allowQuery wird zusätzlich der Query-Parameter "token" akzeptiert (für EventSource, das keine Header setzen kann).
+func authenticate(allowQuery bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if secret == "" && allowQuery {
+			secret = c.Query("token")
+		}
+		if secret == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token fehlt"})
+			return
+		}
+
+		tokensMu.RLock()
+		t, ok := tokens[hashToken(secret)]
+		tokensMu.RUnlock()
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Ungültiges Token"})
+			return
+		}
+
+		c.Set("token", t)
+		c.Next()
+	}
+}
+
+// Middleware, die nur Admin-Tokens durchlässt. Muss nach authenticate eingehängt werden.
+func requireAdmin(c *gin.Context) {
+	if currentToken(c).Role != roleAdmin {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin-Token erforderlich"})
+		return
+	}
+	c.Next()
+}
+
+// Liefert das von authenticate geprüfte Token der aktuellen Anfrage
+func currentToken(c *gin.Context) Token {
+	t, _ := c.Get("token")
+	token, _ := t.(Token)
+	return token
+}
+
+// Prüft, ob das Token der Anfrage auf den Bin zugreifen darf.
+// Für Schreibzugriffe ist ein Admin- oder read-write-Token nötig. Andernfalls wird mit 403 geantwortet.
+func authorizeBin(c *gin.Context, bin string, write bool) bool {
+	t := currentToken(c)
+	allowed := t.Role == roleAdmin ||
+		(t.Bin == bin && (t.Role == roleReadWrite || (t.Role == roleReadOnly && !write)))
+
+	if !allowed {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Kein Zugriff auf diesen Bin"})
+	}
+	return allowed
+}
+
+// Gibt alle Tokens ohne Hash sortiert nach Erstelldatum aus
+func listTokens(c *gin.Context) {
+	tokensMu.RLock()
+	list := make([]Token, 0, len(tokens))
+	for _, t := range tokens {
+		t.Hash = ""
+		list = append(list, t)
+	}
+	tokensMu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+
+	c.JSON(http.StatusOK, list)
+}
+
+// Legt ein neues Token an. Erwartet ein JSON-Objekt der Form {"name": "...", "role": "...", "bin": "..."}.
+// Der Klartext des Tokens wird nur in dieser Antwort ausgegeben.
+func createToken(c *gin.Context) {
+	var body struct {
+		Name string `json:"name"`
+		Role string `json:"role"`
+		Bin  string `json:"bin"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "Ungültiger Body")
+		return
+	}
+
+	switch body.Role {
+	case roleAdmin:
+		body.Bin = ""
+	case roleReadOnly, roleReadWrite:
+		if !binExists(body.Bin) {
+			c.String(http.StatusBadRequest, "Bin nicht gefunden")
+			return
+		}
+	default:
+		c.String(http.StatusBadRequest, "Ungültige Rolle")
+		return
+	}
+
+	secret := generateTokenSecret()
+	t := Token{
+		ID:        uuid.New().String(),
+		Name:      body.Name,
+		Role:      body.Role,
+		Bin:       body.Bin,
+		Hash:      hashToken(secret),
+		CreatedAt: time.Now(),
+	}
+
+	tokensMu.Lock()
+	tokens[t.Hash] = t
+	tokensMu.Unlock()
+	saveTokenToFile(t)
+
+	t.Hash = ""
+	c.JSON(http.StatusCreated, gin.H{"token": secret, "info": t})
+}
+
+// Löscht ein Token anhand seiner ID
+func deleteToken(c *gin.Context) {
+	id := c.Param("id")
+
+	tokensMu.Lock()
+	found := false
+	for hash, t := range tokens {
+		if t.ID == id {
+			delete(tokens, hash)
+			found = true
+		}
+	}
+	tokensMu.Unlock()
+
+	if !found {
+		c.String(http.StatusNotFound, "Token nicht gefunden")
+		return
+	}
+
+	if err := os.Remove(dataPath("tokens", id+".json")); err != nil && !os.IsNotExist(err) {
+		log.Println("Fehler beim Löschen der Datei:", err)
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Entfernt alle Tokens, die an einen gelöschten Bin gebunden sind
+func deleteTokensForBin(bin string) {
+	tokensMu.Lock()
+	defer tokensMu.Unlock()
+
+	for hash, t := range tokens {
+		if t.Bin != bin {
+			continue
+		}
+		delete(tokens, hash)
+		if err := os.Remove(dataPath("tokens", t.ID+".json")); err != nil {
+			log.Println("Fehler beim Löschen der Datei:", err)
+		}
+	}
+}
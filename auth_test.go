@@ -0,0 +1,23 @@
+package inspector
+
+import "testing"
+
+func TestMaskTokenQuery(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+	}{
+		{"/sse", "/sse"},
+		{"/sse?bin=default", "/sse?bin=default"},
+		{"/sse?token=geheim", "/sse?token=********"},
+		{"/ws?bin=a&token=geheim&tag=x", "/ws?bin=a&token=********&tag=x"},
+		{"/sse?token=a&token=b", "/sse?token=********&token=********"},
+		{"/sse?%74oken=geheim", "/sse?%74oken=********"},
+		{"/sse?tokens=1&token", "/sse?tokens=1&token=********"},
+	}
+	for _, tt := range tests {
+		if got := maskTokenQuery(tt.uri); got != tt.want {
+			t.Errorf("maskTokenQuery(%q) = %q, erwartet %q", tt.uri, got, tt.want)
+		}
+	}
+}
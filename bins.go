@@ -0,0 +1,260 @@
+package inspector
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Name des Standard-Bins, in dem alle Anfragen ohne /b/{bin}/-Präfix landen
+const defaultBin = "default"
+
+// Ein Bin ist ein benannter, isolierter Bereich für aufgezeichnete Anfragen.
+// Anfragen an /b/{bin}/... auf dem Capture-Port werden nur in diesem Bin gespeichert.
+// Optional wird für jede Anfrage an den Bin die Webhook-Signatur geprüft.
+type Bin struct {
+	Name      string           `json:"name"`
+	CreatedAt time.Time        `json:"created_at"`
+	Signature *SignatureConfig `json:"signature,omitempty"`
+	Upstream  string           `json:"upstream,omitempty"` // Pass-Through-Modus: Anfragen werden hierhin weitergeleitet
+}
+
+// Liefert eine Kopie des Bins, in der das Secret der Signaturprüfung maskiert ist
+func (b Bin) masked() Bin {
+	if b.Signature != nil {
+		sig := *b.Signature
+		sig.Secret = "***"
+		b.Signature = &sig
+	}
+	return b
+}
+
+// Alle bekannten Bins, nach Namen indiziert
+var (
+	bins   = make(map[string]Bin)
+	binsMu sync.RWMutex
+)
+
+// Erlaubte Bin-Namen: Buchstaben, Ziffern, Binde- und Unterstrich
+var binNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Legt das Verzeichnis ./bins an, in dem jeder Bin als eigene Datei gespeichert wird
+func createBinsDirectory() error {
+	return os.MkdirAll(dataPath("bins"), 0755)
+}
+
+// Lese alle Bins aus dem Verzeichnis ./bins und lege den Standard-Bin an, falls er fehlt
+func restoreBins() {
+	entries, err := os.ReadDir(dataPath("bins"))
+	if err != nil {
+		log.Fatal("Fehler beim Lesen der Bins:", err)
+	}
+
+	binsMu.Lock()
+	defer binsMu.Unlock()
+
+	for _, entry := range entries {
+		data, err := os.ReadFile(dataPath("bins", entry.Name()))
+		if err != nil {
+			log.Println("Fehler beim Lesen der Datei:", err)
+			continue
+		}
+
+		var b Bin
+		if err := json.Unmarshal(data, &b); err != nil {
+			log.Println("Fehler beim Entmarshalling des Bins:", err)
+			continue
+		}
+		bins[b.Name] = b
+	}
+
+	if _, ok := bins[defaultBin]; !ok {
+		b := Bin{Name: defaultBin, CreatedAt: time.Now()}
+		bins[defaultBin] = b
+		saveBinToFile(b)
+	}
+}
+
+// Speichere einen Bin in die Datei ./bins/{name}.json. Die Datei enthält ggf. das Signatur-Secret.
+func saveBinToFile(b Bin) {
+	data, err := json.MarshalIndent(b, "", "    ")
+	if err != nil {
+		log.Println("Fehler beim Marshalling des Bins:", err)
+		return
+	}
+
+	if err := os.WriteFile(dataPath("bins", b.Name+".json"), data, 0600); err != nil {
+		log.Println("Fehler beim Schreiben der Datei:", err)
+	}
+}
+
+// Prüft, ob ein Bin mit dem angegebenen Namen existiert
+func binExists(name string) bool {
+	binsMu.RLock()
+	defer binsMu.RUnlock()
+	_, ok := bins[name]
+	return ok
+}
+
+// Ermittelt den Bin aus dem Pfad einer Anfrage.
+// Pfade der Form /b/{bin}/... gehören zu {bin}, alle anderen zum Standard-Bin.
+func binFromPath(path string) string {
+	if !strings.HasPrefix(path, "/b/") {
+		return defaultBin
+	}
+
+	name := strings.TrimPrefix(path, "/b/")
+	if i := strings.Index(name, "/"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// Liefert den Pfad einer Anfrage relativ zu ihrem Bin, "/b/{bin}/hooks/x" wird also zu "/hooks/x"
+func pathInBin(path string) string {
+	if !strings.HasPrefix(path, "/b/") {
+		return path
+	}
+
+	rest := strings.TrimPrefix(path, "/b/")
+	if i := strings.Index(rest, "/"); i >= 0 {
+		return rest[i:]
+	}
+	return "/"
+}
+
+// Liefert den Bin-Namen aus dem Query-Parameter "bin" oder den Standard-Bin.
+// Existiert der Bin nicht oder darf das Token ihn nicht lesen, wird mit 404 bzw. 403 geantwortet und false zurückgegeben.
+func binFromQuery(c *gin.Context) (string, bool) {
+	name := c.DefaultQuery("bin", defaultBin)
+	if !binExists(name) {
+		c.String(http.StatusNotFound, "Bin nicht gefunden")
+		return "", false
+	}
+	if !authorizeBin(c, name, false) {
+		return "", false
+	}
+	return name, true
+}
+
+// Gibt alle Bins, auf die das Token zugreifen darf, sortiert nach Namen als JSON aus
+func listBins(c *gin.Context) {
+	t := currentToken(c)
+
+	binsMu.RLock()
+	list := make([]Bin, 0, len(bins))
+	for _, b := range bins {
+		if t.Role == roleAdmin || t.Bin == b.Name {
+			list = append(list, b.masked())
+		}
+	}
+	binsMu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	c.JSON(http.StatusOK, list)
+}
+
+// Legt einen neuen Bin an. Erwartet ein JSON-Objekt der Form {"name": "..."}.
+func createBin(c *gin.Context) {
+	var body struct {
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || !binNamePattern.MatchString(body.Name) {
+		c.String(http.StatusBadRequest, "Ungültiger Bin-Name")
+		return
+	}
+
+	binsMu.Lock()
+	if _, ok := bins[body.Name]; ok {
+		binsMu.Unlock()
+		c.String(http.StatusConflict, "Bin existiert bereits")
+		return
+	}
+	b := Bin{Name: body.Name, CreatedAt: time.Now()}
+	bins[b.Name] = b
+	binsMu.Unlock()
+
+	saveBinToFile(b)
+
+	c.JSON(http.StatusCreated, b)
+}
+
+// Löscht einen Bin samt aller darin gespeicherten Anfragen.
+// Der Standard-Bin kann nicht gelöscht werden.
+func deleteBin(c *gin.Context) {
+	name := c.Param("bin")
+	if name == defaultBin {
+		c.String(http.StatusBadRequest, "Der Standard-Bin kann nicht gelöscht werden")
+		return
+	}
+
+	binsMu.Lock()
+	if _, ok := bins[name]; !ok {
+		binsMu.Unlock()
+		c.String(http.StatusNotFound, "Bin nicht gefunden")
+		return
+	}
+	delete(bins, name)
+	// Unter der Sperre, damit eine gleichzeitige Änderung die Datei nicht neu schreibt
+	if err := os.Remove(dataPath("bins", name+".json")); err != nil {
+		log.Println("Fehler beim Löschen der Datei:", err)
+	}
+	binsMu.Unlock()
+	deleteTokensForBin(name)
+	deleteSchemaRulesForBin(name)
+	deleteOpenAPIForBin(name)
+	deleteMockRulesForBin(name)
+	deleteScenariosForBin(name)
+
+	deleted := removeRequestsInBin(name)
+	broadcastEvent(name, eventBinCleared, BinClearedEvent{Bin: name, Deleted: deleted, BinDeleted: true})
+
+	c.Status(http.StatusNoContent)
+}
+
+// Entfernt alle Anfragen des Bins aus der Slice und vom Datenträger und liefert ihre Anzahl
+func removeRequestsInBin(name string) int {
+	requestsMu.Lock()
+	var remaining, removed []Request
+	for _, r := range requests {
+		if r.Bin != name {
+			remaining = append(remaining, r)
+			continue
+		}
+		removed = append(removed, r)
+	}
+	requests = remaining
+	requestsMu.Unlock()
+
+	for _, r := range removed {
+		removeRequestFiles(r)
+	}
+	return len(removed)
+}
+
+// Löscht alle Anfragen eines Bins, der Bin selbst und seine Einstellungen bleiben erhalten
+func clearBin(c *gin.Context) {
+	name := c.Param("bin")
+	if !binExists(name) {
+		c.String(http.StatusNotFound, "Bin nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, name, true) {
+		return
+	}
+
+	deleted := removeRequestsInBin(name)
+	broadcastEvent(name, eventBinCleared, BinClearedEvent{Bin: name, Deleted: deleted})
+	c.JSON(http.StatusOK, gin.H{"deleted": deleted})
+}
@@ -0,0 +1,96 @@
+package inspector
+
+import (
+	"sync"
+	"time"
+)
+
+// Verhalten bei voller Warteschlange (sse_overflow_policy): die älteste Nachricht verwerfen, die neue verwerfen
+// oder den Client trennen. Ein getrennter Browser verbindet sich per EventSource selbst neu.
+const (
+	overflowDropOldest = "drop-oldest"
+	overflowDropNew    = "drop-new"
+	overflowDisconnect = "disconnect"
+)
+
+// Wie lange ein Schreibvorgang an einen SSE-Client höchstens dauern darf, bevor die Verbindung getrennt wird
+const sseWriteTimeout = 30 * time.Second
+
+// Eine begrenzte Warteschlange für die Nachrichten eines SSE-Clients.
+// push blockiert nie, damit ein langsamer Client weder die Aufzeichnung noch andere Clients aufhält.
+type sseQueue struct {
+	mu       sync.Mutex
+	messages []sseEvent
+	size     int
+	policy   string
+	dropped  uint64
+	notify   chan struct{} // signalisiert dem Schreiber neue Nachrichten
+	kicked   chan struct{} // wird geschlossen, wenn der Client getrennt werden soll
+	kickOnce sync.Once
+}
+
+func newSSEQueue(size int, policy string) *sseQueue {
+	return &sseQueue{
+		size:   size,
+		policy: policy,
+		notify: make(chan struct{}, 1),
+		kicked: make(chan struct{}),
+	}
+}
+
+// Hängt ein Ereignis an. Ist die Warteschlange voll, greift das Überlaufverhalten.
+func (q *sseQueue) push(e sseEvent) {
+	// Ein bereits getrennter Client erhält keine Nachrichten mehr
+	select {
+	case <-q.kicked:
+		return
+	default:
+	}
+
+	q.mu.Lock()
+	if len(q.messages) >= q.size {
+		q.dropped++
+		recordSSEDrop(q.policy)
+		switch q.policy {
+		case overflowDropNew:
+			q.mu.Unlock()
+			return
+		case overflowDisconnect:
+			q.mu.Unlock()
+			q.kickOnce.Do(func() { close(q.kicked) })
+			return
+		default:
+			q.messages = q.messages[1:]
+		}
+	}
+	q.messages = append(q.messages, e)
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Entnimmt alle wartenden Ereignisse
+func (q *sseQueue) drain() []sseEvent {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	messages := q.messages
+	q.messages = nil
+	return messages
+}
+
+// Anzahl der bisher verworfenen Nachrichten
+func (q *sseQueue) droppedCount() uint64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.dropped
+}
+
+// Anzahl der Nachrichten, die noch nicht an den Client geschrieben wurden
+func (q *sseQueue) pending() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.messages)
+}
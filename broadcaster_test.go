@@ -0,0 +1,90 @@
+package inspector
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSSEQueueOverflow(t *testing.T) {
+	tests := []struct {
+		policy      string
+		pushed      int
+		wantIDs     []uint64
+		wantDropped uint64
+		wantKicked  bool
+	}{
+		{overflowDropOldest, 3, []uint64{1, 2, 3}, 0, false},
+		{overflowDropOldest, 5, []uint64{3, 4, 5}, 2, false},
+		{overflowDropNew, 5, []uint64{1, 2, 3}, 2, false},
+		// Nach dem Trennen wird nichts mehr angenommen oder gezählt
+		{overflowDisconnect, 5, []uint64{1, 2, 3}, 1, true},
+		{overflowDisconnect, 3, []uint64{1, 2, 3}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s mit %d", tt.policy, tt.pushed), func(t *testing.T) {
+			metricsMu.Lock()
+			before := sseDroppedMessages[tt.policy]
+			metricsMu.Unlock()
+
+			q := newSSEQueue(3, tt.policy)
+			for id := uint64(1); id <= uint64(tt.pushed); id++ {
+				q.push(sseEvent{ID: id})
+			}
+
+			if n := q.pending(); n != len(tt.wantIDs) {
+				t.Errorf("%d wartende Nachrichten, erwartet %d", n, len(tt.wantIDs))
+			}
+			var ids []uint64
+			for _, e := range q.drain() {
+				ids = append(ids, e.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("Nachrichten %v, erwartet %v", ids, tt.wantIDs)
+			}
+			if q.pending() != 0 {
+				t.Errorf("Warteschlange nach drain nicht leer")
+			}
+			if got := q.droppedCount(); got != tt.wantDropped {
+				t.Errorf("%d verworfen, erwartet %d", got, tt.wantDropped)
+			}
+
+			metricsMu.Lock()
+			recorded := sseDroppedMessages[tt.policy] - before
+			metricsMu.Unlock()
+			if recorded != tt.wantDropped {
+				t.Errorf("Metrik zählt %d, erwartet %d", recorded, tt.wantDropped)
+			}
+
+			select {
+			case <-q.kicked:
+				if !tt.wantKicked {
+					t.Error("Client getrennt")
+				}
+			default:
+				if tt.wantKicked {
+					t.Error("Client nicht getrennt")
+				}
+			}
+		})
+	}
+}
+
+// Der Schreiber wird für mehrere wartende Nachrichten nur einmal geweckt, push blockiert dabei nie
+func TestSSEQueueNotify(t *testing.T) {
+	q := newSSEQueue(10, overflowDropOldest)
+	for id := uint64(1); id <= 5; id++ {
+		q.push(sseEvent{ID: id})
+	}
+	select {
+	case <-q.notify:
+	default:
+		t.Fatal("keine Benachrichtigung")
+	}
+	select {
+	case <-q.notify:
+		t.Error("zweite Benachrichtigung für dieselben Nachrichten")
+	default:
+	}
+}
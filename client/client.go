@@ -0,0 +1,350 @@
+// Package client ist der Go-Client für die Management-API des Inspectors (Port 8081).
+// Die Typen der Antworten sind dieselben wie im Server, z. B. inspector.Request und inspector.MockRule.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	inspector "awesomeProject"
+)
+
+// Ein Client für die Management-API
+type Client struct {
+	BaseURL    string // z. B. "http://localhost:8081"
+	Token      string // API-Token, wird als Bearer-Token gesendet
+	HTTPClient *http.Client
+}
+
+// Erstellt einen Client für die Management-API unter baseURL
+func New(baseURL, token string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), Token: token, HTTPClient: http.DefaultClient}
+}
+
+// Eine Fehlerantwort des Servers
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("inspector: %d %s", e.StatusCode, e.Message)
+}
+
+// Wird von Wait geliefert, wenn bis zum Ablauf der Wartezeit nicht genug passende Anfragen eingetroffen sind
+var ErrTimeout = errors.New("inspector: Wartezeit abgelaufen")
+
+// Wird von Wait geliefert, wenn der Server mehr Ereignisse erhielt, als er für den Aufruf puffert.
+// Mit dem Cursor des Ergebnisses wird erneut gewartet.
+var ErrOverflow = errors.New("inspector: zu viele Ereignisse, mit dem Cursor erneut warten")
+
+// Filter für Anfragen, wie die Query-Parameter von /view-requests.
+// Leere Felder schränken nicht ein.
+type Filter struct {
+	Methods []string               // eine der Methoden
+	Path    string                 // Pfadmuster relativ zum Bin, z. B. "/orders/*"
+	Headers map[string]string      // Name und Muster des Werts, leeres Muster: Header muss vorhanden sein
+	Fields  map[string]interface{} // Feldpfad im Body wie "order.id" und erwarteter Wert
+	Tags    []string
+	Starred *bool
+	Note    string
+}
+
+// Fügt die Query-Parameter des Filters an
+func (f Filter) addTo(q url.Values) error {
+	for _, m := range f.Methods {
+		q.Add("method", m)
+	}
+	if f.Path != "" {
+		q.Set("path", f.Path)
+	}
+	for name, pattern := range f.Headers {
+		if pattern == "" {
+			q.Add("header", name)
+		} else {
+			q.Add("header", name+":"+pattern)
+		}
+	}
+	for field, value := range f.Fields {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("inspector: Wert für Feld %q: %w", field, err)
+		}
+		q.Add("field", field+":"+string(data))
+	}
+	for _, tag := range f.Tags {
+		q.Add("tag", tag)
+	}
+	if f.Starred != nil {
+		q.Set("starred", strconv.FormatBool(*f.Starred))
+	}
+	if f.Note != "" {
+		q.Set("note", f.Note)
+	}
+	return nil
+}
+
+// Sendet eine Anfrage an die Management-API und dekodiert die JSON-Antwort nach out, falls out nicht nil ist
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := c.send(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return c.HTTPClient.Do(req)
+}
+
+// Liest eine Fehlerantwort. Der Server antwortet mit Klartext.
+func responseError(resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	return &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(data))}
+}
+
+// Eine Seite gespeicherter Anfragen, neueste zuerst.
+// Next ist der Cursor für die folgende Seite und leer, wenn es keine weitere gibt.
+type Page struct {
+	Requests []inspector.Request
+	Next     string
+}
+
+// Liefert eine Seite der Anfragen eines Bins. Mit leerem cursor beginnt sie bei der neuesten Anfrage.
+func (c *Client) ListRequests(ctx context.Context, bin string, f Filter, cursor string) (Page, error) {
+	q := url.Values{"bin": {bin}}
+	if err := f.addTo(q); err != nil {
+		return Page{}, err
+	}
+	if cursor != "" {
+		q.Set("before", cursor)
+	}
+
+	resp, err := c.send(ctx, http.MethodGet, "/view-requests", q, nil)
+	if err != nil {
+		return Page{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return Page{}, responseError(resp)
+	}
+
+	// Die Seitengröße legt der Server fest (page_size), den Cursor der nächsten Seite liefert er im Header
+	page := Page{Next: resp.Header.Get("X-Next-Before")}
+	if err := json.NewDecoder(resp.Body).Decode(&page.Requests); err != nil {
+		return Page{}, err
+	}
+	return page, nil
+}
+
+// Liefert alle Anfragen eines Bins, die auf den Filter passen, neueste zuerst
+func (c *Client) AllRequests(ctx context.Context, bin string, f Filter) ([]inspector.Request, error) {
+	var all []inspector.Request
+	cursor := ""
+	for {
+		page, err := c.ListRequests(ctx, bin, f, cursor)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, page.Requests...)
+		if page.Next == "" {
+			return all, nil
+		}
+		cursor = page.Next
+	}
+}
+
+// Liefert eine gespeicherte Anfrage
+func (c *Client) GetRequest(ctx context.Context, id string) (inspector.Request, error) {
+	var r inspector.Request
+	err := c.do(ctx, http.MethodGet, "/requests/"+url.PathEscape(id), nil, nil, &r)
+	return r, err
+}
+
+// Liest den gespeicherten Body einer Anfrage über den Link auf den Capture-Server. Anfragen ohne Body liefern nil.
+func (c *Client) Body(ctx context.Context, r inspector.Request) ([]byte, error) {
+	if r.LinkToFile == "" {
+		return nil, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.LinkToFile, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Löscht eine gespeicherte Anfrage
+func (c *Client) DeleteRequest(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/requests/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// Optionen für Wait
+type WaitOptions struct {
+	Count   int           // Anzahl der erwarteten Anfragen, Standard 1
+	Timeout time.Duration // Standard 30 Sekunden, höchstens 5 Minuten
+	Cursor  *uint64       // mit Cursor zählen auch Anfragen, die danach bereits eingetroffen sind
+}
+
+// Wartet auf passende Anfragen. Läuft die Zeit ab, wird ErrTimeout mit den bis dahin gefundenen Anfragen geliefert,
+// bei zu vielen Ereignissen ebenso ErrOverflow. Der Cursor des Ergebnisses kann für den nächsten Aufruf übergeben werden.
+func (c *Client) Wait(ctx context.Context, bin string, f Filter, opts WaitOptions) (inspector.WaitResult, error) {
+	q := url.Values{"bin": {bin}}
+	if err := f.addTo(q); err != nil {
+		return inspector.WaitResult{}, err
+	}
+	if opts.Count > 0 {
+		q.Set("count", strconv.Itoa(opts.Count))
+	}
+	if opts.Timeout > 0 {
+		q.Set("timeout", opts.Timeout.String())
+	}
+	if opts.Cursor != nil {
+		q.Set("cursor", strconv.FormatUint(*opts.Cursor, 10))
+	}
+
+	resp, err := c.send(ctx, http.MethodGet, "/requests/wait", q, nil)
+	if err != nil {
+		return inspector.WaitResult{}, err
+	}
+	defer resp.Body.Close()
+
+	var result inspector.WaitResult
+	switch resp.StatusCode {
+	case http.StatusOK:
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		return result, err
+	case http.StatusRequestTimeout:
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return result, err
+		}
+		return result, ErrTimeout
+	case http.StatusServiceUnavailable:
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			return result, err
+		}
+		return result, ErrOverflow
+	default:
+		return result, responseError(resp)
+	}
+}
+
+// Liefert die Mock-Regeln eines Bins
+func (c *Client) ListMockRules(ctx context.Context, bin string) ([]inspector.MockRule, error) {
+	var rules []inspector.MockRule
+	err := c.do(ctx, http.MethodGet, "/mocks", url.Values{"bin": {bin}}, nil, &rules)
+	return rules, err
+}
+
+// Legt eine Mock-Regel an und liefert sie mit ID zurück
+func (c *Client) CreateMockRule(ctx context.Context, rule inspector.MockRule) (inspector.MockRule, error) {
+	var created inspector.MockRule
+	err := c.do(ctx, http.MethodPost, "/mocks", nil, rule, &created)
+	return created, err
+}
+
+// Ersetzt die Mock-Regel mit rule.ID
+func (c *Client) UpdateMockRule(ctx context.Context, rule inspector.MockRule) (inspector.MockRule, error) {
+	var updated inspector.MockRule
+	err := c.do(ctx, http.MethodPut, "/mocks/"+url.PathEscape(rule.ID), nil, rule, &updated)
+	return updated, err
+}
+
+// Löscht eine Mock-Regel
+func (c *Client) DeleteMockRule(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, "/mocks/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// Liefert alle Bins, auf die das Token zugreifen darf
+func (c *Client) ListBins(ctx context.Context) ([]inspector.Bin, error) {
+	var bins []inspector.Bin
+	err := c.do(ctx, http.MethodGet, "/bins", nil, nil, &bins)
+	return bins, err
+}
+
+// Legt einen Bin an (nur mit Admin-Token)
+func (c *Client) CreateBin(ctx context.Context, name string) (inspector.Bin, error) {
+	var b inspector.Bin
+	err := c.do(ctx, http.MethodPost, "/bins", nil, map[string]string{"name": name}, &b)
+	return b, err
+}
+
+// Löscht einen Bin samt seiner Anfragen (nur mit Admin-Token)
+func (c *Client) DeleteBin(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/bins/"+url.PathEscape(name), nil, nil, nil)
+}
+
+// Löscht alle Anfragen eines Bins und liefert ihre Anzahl
+func (c *Client) ClearBin(ctx context.Context, name string) (int, error) {
+	var result struct {
+		Deleted int `json:"deleted"`
+	}
+	err := c.do(ctx, http.MethodDelete, "/bins/"+url.PathEscape(name)+"/requests", nil, nil, &result)
+	return result.Deleted, err
+}
+
+// Sendet eine gespeicherte Anfrage erneut an target und liefert die Antwort des Ziels (nur mit Admin-Token)
+func (c *Client) Replay(ctx context.Context, id, target string) (inspector.RecordedResponse, error) {
+	var resp inspector.RecordedResponse
+	err := c.do(ctx, http.MethodPost, "/requests/"+url.PathEscape(id)+"/replay", nil, map[string]string{"target": target}, &resp)
+	return resp, err
+}
+
+// Exportiert die Anfragen eines Bins, die auf den Filter passen, als HTTP Archive
+func (c *Client) ExportHAR(ctx context.Context, bin string, f Filter) (inspector.HAR, error) {
+	q := url.Values{"bin": {bin}, "format": {"har"}}
+	if err := f.addTo(q); err != nil {
+		return inspector.HAR{}, err
+	}
+	var har inspector.HAR
+	err := c.do(ctx, http.MethodGet, "/view-requests", q, nil, &har)
+	return har, err
+}
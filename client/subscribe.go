@@ -0,0 +1,322 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	inspector "awesomeProject"
+	"golang.org/x/net/websocket"
+)
+
+// Ein Ereignis aus /sse oder /ws, siehe events.go im Server
+type Event struct {
+	ID      uint64
+	Type    string // z. B. "request.created"
+	Data    json.RawMessage
+	Request *inspector.Request // bei request.created und request.updated gesetzt
+}
+
+// Optionen für Subscribe
+type SubscribeOptions struct {
+	Filter      Filter
+	WebSocket   bool          // /ws statt /sse verwenden
+	LastEventID uint64        // Ereignisse nach dieser Nummer nachholen, 0: erst ab der Verbindung
+	RetryDelay  time.Duration // Wartezeit vor einer neuen Verbindung, Standard ist der Vorschlag des Servers
+	Buffer      int           // Größe des Kanals, Standard 100
+}
+
+// Ein Abonnement der Ereignisse eines Bins.
+// Bricht die Verbindung ab, verbindet es sich neu und holt die verpassten Ereignisse per Last-Event-ID nach.
+type Subscription struct {
+	// Die empfangenen Ereignisse. Der Kanal wird geschlossen, wenn das Abonnement endet.
+	Events <-chan Event
+
+	cancel context.CancelFunc
+	done   chan struct{}
+	mu     sync.Mutex
+	err    error
+}
+
+// Beendet das Abonnement und wartet, bis der Kanal geschlossen ist
+func (s *Subscription) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// Liefert den Grund, aus dem das Abonnement geendet hat, nach Close nil
+func (s *Subscription) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Abonniert die Ereignisse eines Bins. Fehler beim ersten Verbindungsaufbau werden direkt geliefert,
+// Antworten mit 4xx beenden das Abonnement, alle übrigen Fehler führen zu einer neuen Verbindung.
+func (c *Client) Subscribe(ctx context.Context, bin string, opts SubscribeOptions) (*Subscription, error) {
+	q := url.Values{"bin": {bin}}
+	if err := opts.Filter.addTo(q); err != nil {
+		return nil, err
+	}
+	if opts.Buffer <= 0 {
+		opts.Buffer = 100
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	events := make(chan Event, opts.Buffer)
+	s := &Subscription{Events: events, cancel: cancel, done: make(chan struct{})}
+	st := &stream{client: c, query: q, opts: opts, lastID: opts.LastEventID, resume: opts.LastEventID > 0, retry: 3 * time.Second}
+	if opts.RetryDelay > 0 {
+		st.retry = opts.RetryDelay
+	}
+
+	conn, err := st.connect(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go func() {
+		defer close(s.done)
+		defer close(events)
+		for {
+			err := conn.read(ctx, st, events)
+			if ctx.Err() != nil {
+				return
+			}
+			var apiErr *Error
+			if errors.As(err, &apiErr) && apiErr.StatusCode < 500 {
+				s.mu.Lock()
+				s.err = err
+				s.mu.Unlock()
+				return
+			}
+
+			// Neu verbinden, bis es gelingt oder das Abonnement beendet wird
+			for {
+				select {
+				case <-time.After(st.retry):
+				case <-ctx.Done():
+					return
+				}
+				if conn, err = st.connect(ctx); err == nil {
+					break
+				}
+				if errors.As(err, &apiErr) && apiErr.StatusCode < 500 {
+					s.mu.Lock()
+					s.err = err
+					s.mu.Unlock()
+					return
+				}
+			}
+		}
+	}()
+	return s, nil
+}
+
+// Der Zustand über mehrere Verbindungen eines Abonnements
+type stream struct {
+	client *Client
+	query  url.Values
+	opts   SubscribeOptions
+	lastID uint64
+	resume bool
+	retry  time.Duration
+}
+
+// Eine offene Verbindung zu /sse oder /ws
+type eventConn interface {
+	// Liest Ereignisse, bis die Verbindung abbricht
+	read(ctx context.Context, st *stream, events chan<- Event) error
+}
+
+func (st *stream) connect(ctx context.Context) (eventConn, error) {
+	q := url.Values{}
+	for k, v := range st.query {
+		q[k] = v
+	}
+	if st.resume {
+		q.Set("last_event_id", strconv.FormatUint(st.lastID, 10))
+	}
+
+	if st.opts.WebSocket {
+		return st.connectWebSocket(q)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, st.client.BaseURL+"/sse?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if st.client.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+st.client.Token)
+	}
+	resp, err := st.client.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+	return &sseConn{resp: resp}, nil
+}
+
+func (st *stream) connectWebSocket(q url.Values) (eventConn, error) {
+	u, err := url.Parse(st.client.BaseURL + "/ws?" + q.Encode())
+	if err != nil {
+		return nil, err
+	}
+	origin := *u
+	origin.Path, origin.RawQuery = "", ""
+	if u.Scheme == "https" {
+		u.Scheme = "wss"
+	} else {
+		u.Scheme = "ws"
+	}
+
+	config, err := websocket.NewConfig(u.String(), origin.String())
+	if err != nil {
+		return nil, err
+	}
+	if st.client.Token != "" {
+		config.Header.Set("Authorization", "Bearer "+st.client.Token)
+	}
+	ws, err := websocket.DialConfig(config)
+	if err != nil {
+		var dialErr *websocket.DialError
+		if errors.As(err, &dialErr) && errors.Is(dialErr.Err, websocket.ErrBadStatus) {
+			// Der Server hat den Verbindungsaufbau abgelehnt, z. B. wegen eines ungültigen Tokens
+			return nil, &Error{StatusCode: http.StatusBadRequest, Message: "WebSocket-Verbindung abgelehnt"}
+		}
+		return nil, err
+	}
+	return &wsConn{ws: ws}, nil
+}
+
+// Übergibt ein Ereignis an den Kanal und merkt sich seine Nummer für die nächste Verbindung
+func (st *stream) deliver(ctx context.Context, events chan<- Event, e Event) bool {
+	if e.Type == "request.created" || e.Type == "request.updated" {
+		var r inspector.Request
+		if err := json.Unmarshal(e.Data, &r); err == nil {
+			e.Request = &r
+		}
+	}
+	select {
+	case events <- e:
+	case <-ctx.Done():
+		return false
+	}
+	if e.ID > 0 {
+		st.started(e.ID)
+	}
+	return true
+}
+
+// Merkt sich die Nummer, ab der die nächste Verbindung Ereignisse nachholt
+func (st *stream) started(id uint64) {
+	st.lastID = id
+	st.resume = true
+}
+
+type sseConn struct {
+	resp *http.Response
+}
+
+// Liest Ereignisse im Format von Server-Sent Events
+func (c *sseConn) read(ctx context.Context, st *stream, events chan<- Event) error {
+	defer c.resp.Body.Close()
+
+	var e Event
+	var data []string
+	hasID := false
+	scanner := bufio.NewScanner(c.resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			// Eine Leerzeile schließt das Ereignis ab
+			if len(data) > 0 {
+				e.Data = json.RawMessage(strings.Join(data, "\n"))
+				if e.Type == "" {
+					e.Type = "message"
+				}
+				if !st.deliver(ctx, events, e) {
+					return ctx.Err()
+				}
+			} else if hasID {
+				// Die Startnummer, die der Server beim Verbindungsaufbau sendet
+				st.started(e.ID)
+			}
+			e, data, hasID = Event{}, nil, false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			if id, err := strconv.ParseUint(value, 10, 64); err == nil {
+				e.ID, hasID = id, true
+			}
+		case "event":
+			e.Type = value
+		case "data":
+			data = append(data, value)
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms > 0 && st.opts.RetryDelay == 0 {
+				st.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errors.New("inspector: Verbindung vom Server beendet")
+}
+
+type wsConn struct {
+	ws *websocket.Conn
+}
+
+// Nachrichten von /ws. Nur "event" wird als Ereignis weitergegeben, "connected" nennt die Startnummer.
+type wsMessage struct {
+	Type  string          `json:"type"`
+	ID    uint64          `json:"id"`
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+func (c *wsConn) read(ctx context.Context, st *stream, events chan<- Event) error {
+	// Die Verbindung wird geschlossen, sobald das Abonnement endet, damit Receive zurückkehrt
+	stop := context.AfterFunc(ctx, func() { c.ws.Close() })
+	defer stop()
+	defer c.ws.Close()
+
+	for {
+		var m wsMessage
+		if err := websocket.JSON.Receive(c.ws, &m); err != nil {
+			return err
+		}
+		if m.Type == "connected" {
+			st.started(m.ID)
+			continue
+		}
+		if m.Type != "event" {
+			continue
+		}
+		if !st.deliver(ctx, events, Event{ID: m.ID, Type: m.Event, Data: m.Data}) {
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	inspector "awesomeProject"
+)
+
+// Merkt sich die geöffneten Verbindungen, damit der Test sie trennen kann
+type connTracker struct {
+	mu    sync.Mutex
+	conns []net.Conn
+}
+
+func (ct *connTracker) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+	if err == nil {
+		ct.mu.Lock()
+		ct.conns = append(ct.conns, conn)
+		ct.mu.Unlock()
+	}
+	return conn, err
+}
+
+func (ct *connTracker) closeAll() {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	for _, conn := range ct.conns {
+		conn.Close()
+	}
+	ct.conns = nil
+}
+
+// Bricht die Verbindung vor dem ersten Ereignis ab, wird ab der Startnummer des Servers nachgeholt
+func TestSubscribeResumesBeforeFirstEvent(t *testing.T) {
+	s := inspector.NewServer(t)
+	capture := func(path string) {
+		resp, err := http.Post(s.URL("default", path), "text/plain", strings.NewReader("x"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	// Eine Anfrage vor dem Abonnement, die nicht nachgeholt werden darf
+	capture("/vorher")
+
+	tracker := &connTracker{}
+	c := New(s.ManagementURL, s.AdminToken)
+	c.HTTPClient = &http.Client{Transport: &http.Transport{DialContext: tracker.dial}}
+
+	sub, err := c.Subscribe(context.Background(), "default", SubscribeOptions{RetryDelay: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	// Warten, bis die Startnummer gelesen ist, dann trennen und während der Wartezeit aufzeichnen
+	time.Sleep(100 * time.Millisecond)
+	tracker.closeAll()
+	capture("/getrennt")
+
+	select {
+	case e := <-sub.Events:
+		if e.Request == nil || !strings.HasSuffix(e.Request.URL, "/getrennt") {
+			t.Errorf("Ereignis %s %s, erwartet die Anfrage /getrennt", e.Type, e.Data)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Anfrage während der Trennung nicht nachgeholt")
+	}
+}
+
+// Das Token wird auch bei /ws im Authorization-Header gesendet
+func TestSubscribeWebSocket(t *testing.T) {
+	s := inspector.NewServer(t)
+	c := New(s.ManagementURL, s.AdminToken)
+	sub, err := c.Subscribe(context.Background(), "default", SubscribeOptions{WebSocket: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sub.Close()
+
+	if _, err := New(s.ManagementURL, "falsch").Subscribe(context.Background(), "default", SubscribeOptions{WebSocket: true}); err == nil {
+		t.Error("Verbindung mit falschem Token angenommen")
+	}
+
+	// Die Startnummer kommt vor der Anfrage an
+	time.Sleep(100 * time.Millisecond)
+	resp, err := http.Post(s.URL("default", "/ws"), "text/plain", strings.NewReader("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	select {
+	case e := <-sub.Events:
+		if e.Request == nil || e.ID == 0 {
+			t.Errorf("Ereignis %s %s, erwartet die Anfrage", e.Type, e.Data)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("kein Ereignis")
+	}
+}
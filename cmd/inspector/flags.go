@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"awesomeProject/client"
+)
+
+// Ein Fehler in der Verwendung eines Befehls, z. B. ein fehlendes Argument
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+// Wird geliefert, wenn das flag-Paket die Optionen nicht lesen konnte
+var errFlags = errors.New("ungültige Optionen")
+
+// Eine Option, die mehrfach angegeben werden kann, z. B. --method GET --method POST
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// Erstellt die Optionen eines Befehls. args beschreibt die Argumente ohne Optionen für die Hilfe.
+func newFlagSet(name, args, description string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Verwendung: inspector %s %s\n\n%s\n\nOptionen:\n", name, args, description)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// Liest Optionen und Argumente in beliebiger Reihenfolge, z. B. "replay <id> --to URL".
+// Das flag-Paket hört sonst beim ersten Argument ohne "-" auf.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, errFlags
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// Verbindung zur Management-API
+type connectionFlags struct {
+	server string
+	token  string
+}
+
+func addConnectionFlags(fs *flag.FlagSet) *connectionFlags {
+	f := &connectionFlags{}
+	server := os.Getenv("INSPECTOR_URL")
+	if server == "" {
+		server = "http://localhost:8081"
+	}
+	fs.StringVar(&f.server, "server", server, "Adresse der Management-API ($INSPECTOR_URL)")
+	fs.StringVar(&f.token, "token", os.Getenv("INSPECTOR_TOKEN"), "API-Token ($INSPECTOR_TOKEN)")
+	return f
+}
+
+func (f *connectionFlags) client() *client.Client {
+	return client.New(f.server, f.token)
+}
+
+// Filteroptionen wie die Query-Parameter von /view-requests
+type filterFlags struct {
+	methods listFlag
+	path    string
+	headers listFlag
+	fields  listFlag
+	tags    listFlag
+	starred bool
+	note    string
+}
+
+func addFilterFlags(fs *flag.FlagSet) *filterFlags {
+	f := &filterFlags{}
+	fs.Var(&f.methods, "method", "nur Anfragen mit dieser Methode (mehrfach möglich)")
+	fs.StringVar(&f.path, "path", "", "Pfadmuster relativ zum Bin, z. B. /orders/*")
+	fs.Var(&f.headers, "header", "Name oder Name:Muster eines Headers (mehrfach möglich)")
+	fs.Var(&f.fields, "field", "Feldpfad:Wert im JSON-Body, z. B. order.id:42 (mehrfach möglich)")
+	fs.Var(&f.tags, "tag", "nur Anfragen mit diesem Tag (mehrfach möglich)")
+	fs.BoolVar(&f.starred, "starred", false, "nur markierte Anfragen")
+	fs.StringVar(&f.note, "note", "", "Text in der Notiz")
+	return f
+}
+
+// Wandelt die Optionen in einen Filter des Clients um
+func (f *filterFlags) filter() (client.Filter, error) {
+	filter := client.Filter{Methods: f.methods, Path: f.path, Tags: f.tags, Note: f.note}
+	if f.starred {
+		filter.Starred = &f.starred
+	}
+	for _, h := range f.headers {
+		name, pattern, _ := strings.Cut(h, ":")
+		if filter.Headers == nil {
+			filter.Headers = make(map[string]string)
+		}
+		filter.Headers[strings.TrimSpace(name)] = strings.TrimSpace(pattern)
+	}
+	for _, field := range f.fields {
+		path, raw, ok := strings.Cut(field, ":")
+		if !ok || path == "" {
+			return filter, usageError(fmt.Sprintf("--field %q: erwartet Feldpfad:Wert", field))
+		}
+		// Der Wert ist JSON, ungültiges JSON gilt als Zeichenkette: --field status:paid
+		var value interface{}
+		if err := json.Unmarshal([]byte(raw), &value); err != nil {
+			value = raw
+		}
+		if filter.Fields == nil {
+			filter.Fields = make(map[string]interface{})
+		}
+		filter.Fields[path] = value
+	}
+	return filter, nil
+}
@@ -0,0 +1,82 @@
+// Der HTTP-Request-Inspector als eigenständiges Programm.
+// Die Logik liegt im importierbaren Paket im Wurzelverzeichnis des Moduls.
+//
+// Ohne Befehl oder mit "serve" startet das Programm den Server. Die übrigen Befehle sprechen
+// mit einem laufenden Server über die Management-API, siehe usage.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	inspector "awesomeProject"
+)
+
+const usage = `Verwendung: inspector <Befehl> [Optionen]
+
+Befehle:
+  serve [Optionen]           Capture-Server (8080) und Management-API (8081) starten
+  reencrypt [Optionen]       gespeicherte Dateien mit einem neuen Schlüssel verschlüsseln
+  tail                       neue Anfragen eines Bins live anzeigen
+  ls                         gespeicherte Anfragen eines Bins auflisten
+  show <id>                  eine Anfrage mit Headern und Body anzeigen
+  replay <id> --to <URL>     eine Anfrage erneut an ein Ziel senden (Admin-Token)
+  export --format har        Anfragen eines Bins als HTTP Archive exportieren
+  rules apply -f <Datei>     Mock-Regeln aus einer YAML-Datei anlegen oder aktualisieren
+
+serve und reencrypt lesen die Konfiguration aus --config (YAML oder TOML), den Umgebungsvariablen
+und ihren Optionen, siehe inspector serve -h. Die übrigen Befehle verbinden sich mit --server (Standard $INSPECTOR_URL
+oder http://localhost:8081) und dem Token aus --token (Standard $INSPECTOR_TOKEN).
+Hilfe zu einem Befehl: inspector <Befehl> -h
+`
+
+// Die Befehle, die einen laufenden Server verwenden
+var commands = map[string]func(args []string) error{
+	"tail":   runTail,
+	"ls":     runList,
+	"show":   runShow,
+	"replay": runReplay,
+	"export": runExport,
+	"rules":  runRules,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		inspector.Main()
+		return
+	}
+
+	switch name := os.Args[1]; {
+	case name == "serve" || name == "reencrypt":
+		// Main wertet Befehl und Optionen selbst aus
+		inspector.Main()
+	case name == "help" || name == "-h" || name == "-help" || name == "--help":
+		fmt.Print(usage)
+	case strings.HasPrefix(name, "-"):
+		// Optionen ohne Befehl gelten für serve, z. B. inspector --config inspector.yaml
+		inspector.Main()
+	default:
+		run, ok := commands[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unbekannter Befehl %q\n\n%s", name, usage)
+			os.Exit(2)
+		}
+		if err := run(os.Args[2:]); err != nil {
+			switch {
+			case errors.Is(err, flag.ErrHelp):
+			case errors.Is(err, errFlags):
+				// Das flag-Paket hat den Fehler bereits mit der Hilfe ausgegeben
+				os.Exit(2)
+			case errors.As(err, new(usageError)):
+				fmt.Fprintf(os.Stderr, "inspector %s: %s\nHilfe: inspector %s -h\n", name, err, name)
+				os.Exit(2)
+			default:
+				fmt.Fprintf(os.Stderr, "inspector %s: %s\n", name, err)
+				os.Exit(1)
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI-Farben für die Ausgabe im Terminal
+const (
+	colorReset  = "\033[0m"
+	colorDim    = "\033[2m"
+	colorBold   = "\033[1m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorBlue   = "\033[34m"
+	colorPurple = "\033[35m"
+	colorCyan   = "\033[36m"
+)
+
+// Färbt die Ausgabe nur, wenn sie in ein Terminal geht und NO_COLOR nicht gesetzt ist
+type painter struct {
+	enabled bool
+}
+
+func newPainter(noColor bool) painter {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return painter{}
+	}
+	info, err := os.Stdout.Stat()
+	return painter{enabled: err == nil && info.Mode()&os.ModeCharDevice != 0}
+}
+
+func (p painter) paint(color, s string) string {
+	if !p.enabled || color == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// Farbe einer HTTP-Methode
+func methodColor(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return colorGreen
+	case http.MethodPost:
+		return colorYellow
+	case http.MethodPut, http.MethodPatch:
+		return colorBlue
+	case http.MethodDelete:
+		return colorRed
+	default:
+		return colorPurple
+	}
+}
+
+// Farbe eines Statuscodes
+func statusColor(status int) string {
+	switch {
+	case status >= 500:
+		return colorRed
+	case status >= 400:
+		return colorYellow
+	case status >= 300:
+		return colorCyan
+	case status > 0:
+		return colorGreen
+	default:
+		return colorDim
+	}
+}
+
+// Der Pfad einer aufgezeichneten URL samt Query, ohne Schema und Host
+func requestPath(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.RequestURI()
+}
+
+// Größe in lesbarer Form
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f kB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+// Schreibt Header sortiert nach Namen
+func printHeaders(p painter, headers http.Header) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range headers[name] {
+			fmt.Printf("%s: %s\n", p.paint(colorCyan, name), value)
+		}
+	}
+}
+
+// Schreibt einen Body. Binäre Bodies werden nur mit ihrer Größe angezeigt.
+func printBody(p painter, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+	if !utf8.Valid(body) || strings.ContainsRune(string(body), 0) {
+		fmt.Println(p.paint(colorDim, fmt.Sprintf("<%s binär>", formatSize(len(body)))))
+		return
+	}
+	fmt.Println(strings.TrimRight(string(body), "\n"))
+}
+
+// Schreibt die Felder eines Formulars, das ohne Datei gespeichert wurde
+func printBodyParams(p painter, params map[string]string) {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Printf("%s=%s\n", p.paint(colorCyan, name), params[name])
+	}
+}
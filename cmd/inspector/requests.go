@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	inspector "awesomeProject"
+	"awesomeProject/client"
+)
+
+// Befehl "tail": zeigt neue Anfragen eines Bins an, bis das Programm mit Strg+C beendet wird
+func runTail(args []string) error {
+	fs := newFlagSet("tail", "[Optionen]", "Folgt /sse und zeigt neue Anfragen eines Bins an. Bricht die Verbindung ab, wird sie ohne Verlust wieder aufgebaut.")
+	conn := addConnectionFlags(fs)
+	filters := addFilterFlags(fs)
+	bin := fs.String("bin", "default", "Bin")
+	since := fs.Uint64("since", 0, "Ereignisse nach dieser Nummer nachholen")
+	all := fs.Bool("all", false, "auch Änderungen, Löschungen und andere Ereignisse anzeigen")
+	asJSON := fs.Bool("json", false, "jedes Ereignis als JSON-Zeile ausgeben, z. B. für jq")
+	noColor := fs.Bool("no-color", false, "ohne Farben ausgeben")
+	if rest, err := parseArgs(fs, args); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return usageError("unerwartetes Argument " + rest[0])
+	}
+	filter, err := filters.filter()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	sub, err := conn.client().Subscribe(ctx, *bin, client.SubscribeOptions{Filter: filter, LastEventID: *since})
+	if err != nil {
+		return err
+	}
+	defer sub.Close()
+
+	p := newPainter(*noColor)
+	for e := range sub.Events {
+		switch {
+		case *asJSON:
+			line, _ := json.Marshal(struct {
+				ID    uint64          `json:"id"`
+				Event string          `json:"event"`
+				Data  json.RawMessage `json:"data"`
+			}{e.ID, e.Type, e.Data})
+			fmt.Println(string(line))
+		case e.Type == "request.created" && e.Request != nil:
+			printRequestLine(p, *e.Request)
+		case *all:
+			fmt.Println(p.paint(colorDim, fmt.Sprintf("%s  %s %s", time.Now().Format("15:04:05"), e.Type, e.Data)))
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return sub.Err()
+}
+
+// Eine Zeile je Anfrage für tail
+func printRequestLine(p painter, r inspector.Request) {
+	line := fmt.Sprintf("%s  %s %s", r.Timestamp.Local().Format("15:04:05"), p.paint(methodColor(r.Method), fmt.Sprintf("%-7s", r.Method)), requestPath(r.URL))
+	if r.Status > 0 {
+		line += "  " + p.paint(statusColor(r.Status), fmt.Sprint(r.Status))
+	}
+	if r.BodySize > 0 {
+		line += "  " + formatSize(r.BodySize)
+	}
+	if len(r.Tags) > 0 {
+		line += "  " + p.paint(colorCyan, "#"+strings.Join(r.Tags, " #"))
+	}
+	fmt.Println(line + "  " + p.paint(colorDim, r.ID))
+}
+
+// Befehl "ls": listet die gespeicherten Anfragen eines Bins auf, neueste zuerst
+func runList(args []string) error {
+	fs := newFlagSet("ls", "[Optionen]", "Listet die gespeicherten Anfragen eines Bins auf, neueste zuerst.")
+	conn := addConnectionFlags(fs)
+	filters := addFilterFlags(fs)
+	bin := fs.String("bin", "default", "Bin")
+	before := fs.String("before", "", "Seite nach der Anfrage mit dieser ID")
+	all := fs.Bool("all", false, "alle Seiten statt nur der ersten")
+	asJSON := fs.Bool("json", false, "als JSON ausgeben")
+	noColor := fs.Bool("no-color", false, "ohne Farben ausgeben")
+	if rest, err := parseArgs(fs, args); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return usageError("unerwartetes Argument " + rest[0])
+	}
+	filter, err := filters.filter()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	c := conn.client()
+	var list []inspector.Request
+	next := ""
+	if *all {
+		if *before != "" {
+			return usageError("--all und --before schließen sich aus")
+		}
+		list, err = c.AllRequests(ctx, *bin, filter)
+	} else {
+		var page client.Page
+		page, err = c.ListRequests(ctx, *bin, filter, *before)
+		list, next = page.Requests, page.Next
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(list)
+	}
+
+	p := newPainter(*noColor)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tZEIT\tMETHODE\tPFAD\tSTATUS\tGRÖSSE\tTAGS")
+	for _, r := range list {
+		status := "-"
+		if r.Status > 0 {
+			status = fmt.Sprint(r.Status)
+		}
+		// Alle Zellen einer Spalte werden gleich lang eingefärbt, damit tabwriter sie richtig ausrichtet
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.ID,
+			r.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			p.paint(methodColor(r.Method), r.Method),
+			requestPath(r.URL),
+			p.paint(statusColor(r.Status), status),
+			formatSize(r.BodySize),
+			strings.Join(r.Tags, ","))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if next != "" {
+		fmt.Fprintf(os.Stderr, "Weitere Anfragen: inspector ls --bin %s --before %s\n", *bin, next)
+	}
+	return nil
+}
+
+// Befehl "show": zeigt eine Anfrage mit Headern, Body und der aufgezeichneten Antwort
+func runShow(args []string) error {
+	fs := newFlagSet("show", "<id> [Optionen]", "Zeigt eine gespeicherte Anfrage mit Headern und Body an.")
+	conn := addConnectionFlags(fs)
+	asJSON := fs.Bool("json", false, "als JSON ausgeben")
+	noColor := fs.Bool("no-color", false, "ohne Farben ausgeben")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usageError("genau eine ID erwartet")
+	}
+
+	ctx := context.Background()
+	c := conn.client()
+	r, err := c.GetRequest(ctx, rest[0])
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(r)
+	}
+	body, err := c.Body(ctx, r)
+	if err != nil {
+		return fmt.Errorf("Body kann nicht geladen werden: %w", err)
+	}
+
+	p := newPainter(*noColor)
+	fmt.Println(p.paint(colorBold, p.paint(methodColor(r.Method), r.Method)+" "+requestPath(r.URL)))
+	fmt.Println(p.paint(colorDim, fmt.Sprintf("%s  Bin %s  von %s  ID %s", r.Timestamp.Local().Format("2006-01-02 15:04:05"), r.Bin, r.RemoteAddr, r.ID)))
+	if len(r.Tags) > 0 || r.Starred || r.Note != "" {
+		note := ""
+		if r.Starred {
+			note = "★ "
+		}
+		if len(r.Tags) > 0 {
+			note += "#" + strings.Join(r.Tags, " #") + " "
+		}
+		fmt.Println(p.paint(colorCyan, note+r.Note))
+	}
+	fmt.Println()
+	printHeaders(p, r.Headers)
+	fmt.Println()
+	printBody(p, body)
+	printBodyParams(p, r.BodyParams)
+
+	if r.Response != nil {
+		fmt.Println()
+		fmt.Println(p.paint(colorBold, "Antwort ") + p.paint(statusColor(r.Response.Status), fmt.Sprint(r.Response.Status)))
+		printHeaders(p, r.Response.Headers)
+		fmt.Println()
+		printBody(p, r.Response.Body)
+	}
+	return nil
+}
+
+// Befehl "replay": sendet eine gespeicherte Anfrage erneut an ein Ziel und zeigt dessen Antwort
+func runReplay(args []string) error {
+	fs := newFlagSet("replay", "<id> --to <URL> [Optionen]", "Sendet eine gespeicherte Anfrage erneut. Das Ziel ersetzt Schema und Host, der Bin-Präfix des Pfads entfällt.")
+	conn := addConnectionFlags(fs)
+	target := fs.String("to", "", "Ziel, z. B. http://localhost:3000")
+	asJSON := fs.Bool("json", false, "Antwort als JSON ausgeben")
+	noColor := fs.Bool("no-color", false, "ohne Farben ausgeben")
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usageError("genau eine ID erwartet")
+	}
+	if *target == "" {
+		return usageError("--to fehlt")
+	}
+
+	resp, err := conn.client().Replay(context.Background(), rest[0], *target)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return printJSON(resp)
+	}
+
+	p := newPainter(*noColor)
+	fmt.Println(p.paint(statusColor(resp.Status), fmt.Sprint(resp.Status)) + p.paint(colorDim, fmt.Sprintf("  %d ms", resp.DurationMs)))
+	printHeaders(p, resp.Headers)
+	fmt.Println()
+	printBody(p, resp.Body)
+	return nil
+}
+
+// Befehl "export": schreibt die Anfragen eines Bins als HTTP Archive
+func runExport(args []string) error {
+	fs := newFlagSet("export", "[Optionen]", "Exportiert die Anfragen eines Bins, z. B. zum Öffnen in den Entwicklerwerkzeugen des Browsers.")
+	conn := addConnectionFlags(fs)
+	filters := addFilterFlags(fs)
+	bin := fs.String("bin", "default", "Bin")
+	format := fs.String("format", "har", "Format, derzeit nur har")
+	output := fs.String("o", "", "Datei statt der Standardausgabe")
+	if rest, err := parseArgs(fs, args); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return usageError("unerwartetes Argument " + rest[0])
+	}
+	if *format != "har" {
+		return usageError(fmt.Sprintf("unbekanntes Format %q", *format))
+	}
+	filter, err := filters.filter()
+	if err != nil {
+		return err
+	}
+
+	har, err := conn.client().ExportHAR(context.Background(), *bin, filter)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if *output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(*output, data, 0644)
+}
+
+// Gibt v eingerückt als JSON aus
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	inspector "awesomeProject"
+	"gopkg.in/yaml.v3"
+)
+
+// Die Datei für "rules apply". Die Felder heißen wie im JSON der Mock-Regeln:
+//
+//	bin: orders
+//	rules:
+//	  - method: POST
+//	    path: /orders
+//	    body_fields: {type: express}
+//	    response:
+//	      status: 201
+//	      headers: {Content-Type: application/json}
+//	      body: '{"id": 1}'
+//
+// Statt des Objekts ist auch nur die Liste der Regeln erlaubt.
+type rulesFile struct {
+	Bin   string               `json:"bin"`
+	Rules []inspector.MockRule `json:"rules"`
+}
+
+// Befehl "rules": derzeit nur "rules apply"
+func runRules(args []string) error {
+	fs := newFlagSet("rules apply", "-f <Datei> [Optionen]",
+		"Gleicht die Mock-Regeln eines Bins mit einer YAML-Datei ab. Regeln mit id werden ersetzt, Regeln ohne id\n"+
+			"ersetzen eine bestehende Regel mit gleicher Methode, gleichem Pfad, Szenario, Zustand und gleichen Body-Feldern,\n"+
+			"alle übrigen werden angelegt. Ein erneutes Anwenden derselben Datei ändert daher nichts.")
+	if len(args) == 0 || args[0] != "apply" {
+		if len(args) > 0 && (args[0] == "-h" || args[0] == "-help" || args[0] == "--help") {
+			fs.Usage()
+			return flag.ErrHelp
+		}
+		return usageError("erwartet: rules apply -f <Datei>")
+	}
+	conn := addConnectionFlags(fs)
+	file := fs.String("f", "", "YAML-Datei mit den Regeln, - für die Standardeingabe")
+	bin := fs.String("bin", "", "Bin, falls nicht in der Datei angegeben (Standard \"default\")")
+	prune := fs.Bool("prune", false, "Regeln des Bins löschen, die nicht in der Datei stehen")
+	dryRun := fs.Bool("dry-run", false, "nur anzeigen, was geändert würde")
+	if rest, err := parseArgs(fs, args[1:]); err != nil {
+		return err
+	} else if len(rest) > 0 {
+		return usageError("unerwartetes Argument " + rest[0])
+	}
+	if *file == "" {
+		return usageError("-f fehlt")
+	}
+
+	set, err := readRulesFile(*file)
+	if err != nil {
+		return err
+	}
+	switch {
+	case *bin != "" && set.Bin != "" && *bin != set.Bin:
+		return usageError(fmt.Sprintf("--bin %s widerspricht dem Bin %s aus der Datei", *bin, set.Bin))
+	case *bin != "":
+		set.Bin = *bin
+	case set.Bin == "":
+		set.Bin = "default"
+	}
+
+	ctx := context.Background()
+	c := conn.client()
+	existing, err := c.ListMockRules(ctx, set.Bin)
+	if err != nil {
+		return err
+	}
+
+	// Zu jeder Regel der Datei die bestehende Regel suchen, die sie ersetzt
+	byID := make(map[string]inspector.MockRule, len(existing))
+	byKey := make(map[string]inspector.MockRule, len(existing))
+	for _, rule := range existing {
+		byID[rule.ID] = rule
+		byKey[ruleKey(rule)] = rule
+	}
+	kept := make(map[string]bool)
+	prefix := ""
+	if *dryRun {
+		prefix = "(dry-run) "
+	}
+
+	for i, rule := range set.Rules {
+		if rule.Bin != "" && rule.Bin != set.Bin {
+			return fmt.Errorf("Regel %d: gehört zum Bin %s statt %s", i+1, rule.Bin, set.Bin)
+		}
+		rule.Bin = set.Bin
+
+		var current inspector.MockRule
+		var found bool
+		if rule.ID != "" {
+			if current, found = byID[rule.ID]; !found {
+				return fmt.Errorf("Regel %d: Mock-Regel %s existiert nicht im Bin %s", i+1, rule.ID, set.Bin)
+			}
+		} else {
+			current, found = byKey[ruleKey(rule)]
+		}
+		if found && kept[current.ID] {
+			return fmt.Errorf("Regel %d: ersetzt dieselbe Regel %s wie eine vorherige Regel der Datei", i+1, current.ID)
+		}
+
+		switch {
+		case !found:
+			if !*dryRun {
+				if rule, err = c.CreateMockRule(ctx, rule); err != nil {
+					return fmt.Errorf("Regel %d: %w", i+1, err)
+				}
+			}
+			fmt.Printf("%sangelegt      %s %s %s\n", prefix, ruleID(rule), ruleMethod(rule), rule.Path)
+		case sameRule(current, rule):
+			kept[current.ID] = true
+			fmt.Printf("%sunverändert   %s %s %s\n", prefix, current.ID, ruleMethod(current), current.Path)
+		default:
+			kept[current.ID] = true
+			rule.ID = current.ID
+			if !*dryRun {
+				if rule, err = c.UpdateMockRule(ctx, rule); err != nil {
+					return fmt.Errorf("Regel %d: %w", i+1, err)
+				}
+			}
+			fmt.Printf("%saktualisiert  %s %s %s\n", prefix, rule.ID, ruleMethod(rule), rule.Path)
+		}
+	}
+
+	if *prune {
+		for _, rule := range existing {
+			if kept[rule.ID] {
+				continue
+			}
+			if !*dryRun {
+				if err := c.DeleteMockRule(ctx, rule.ID); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("%sgelöscht      %s %s %s\n", prefix, rule.ID, ruleMethod(rule), rule.Path)
+		}
+	}
+	return nil
+}
+
+// Liest die Regeln aus einer YAML-Datei. YAML wird über JSON dekodiert,
+// damit die Feldnamen der JSON-Tags von inspector.MockRule gelten.
+func readRulesFile(name string) (rulesFile, error) {
+	var data []byte
+	var err error
+	if name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return rulesFile{}, err
+	}
+
+	var raw interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return rulesFile{}, fmt.Errorf("%s: %w", name, err)
+	}
+	if list, ok := raw.([]interface{}); ok {
+		raw = map[string]interface{}{"rules": list}
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return rulesFile{}, fmt.Errorf("%s: %w", name, err)
+	}
+
+	var set rulesFile
+	if err := json.Unmarshal(encoded, &set); err != nil {
+		return rulesFile{}, fmt.Errorf("%s: ungültige Regeln: %w", name, err)
+	}
+	return set, nil
+}
+
+// Die Bedingungen, anhand derer eine Regel ohne id einer bestehenden Regel zugeordnet wird
+func ruleKey(r inspector.MockRule) string {
+	fields, _ := json.Marshal(r.BodyFields)
+	return strings.Join([]string{strings.ToUpper(r.Method), r.Path, r.Scenario, r.RequiredState, string(fields)}, "\x00")
+}
+
+// Vergleicht zwei Regeln ohne ID, Erstellungszeit und Herkunft
+func sameRule(a, b inspector.MockRule) bool {
+	normalize := func(r inspector.MockRule) string {
+		r.ID, r.CreatedAt, r.SourceRequestID = "", time.Time{}, ""
+		r.Method = strings.ToUpper(r.Method)
+		if r.Response.Status == 0 {
+			r.Response.Status = http.StatusOK
+		}
+		data, _ := json.Marshal(r)
+		return string(data)
+	}
+	return normalize(a) == normalize(b)
+}
+
+func ruleMethod(r inspector.MockRule) string {
+	if r.Method == "" {
+		return "*"
+	}
+	return r.Method
+}
+
+func ruleID(r inspector.MockRule) string {
+	if r.ID == "" {
+		return "(neu)"
+	}
+	return r.ID
+}
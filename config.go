@@ -0,0 +1,293 @@
+package inspector
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Die Konfiguration des Servers. Jede Ebene überschreibt die vorherige:
+// Standardwerte, Konfigurationsdatei (YAML oder TOML), Umgebungsvariablen, Optionen von "serve".
+// Die Schlüssel der Datei sind die JSON-Namen, die Umgebungsvariablen und Optionen stehen in configOptions.
+type Config struct {
+	CaptureAddr          string   `json:"capture_addr" yaml:"capture_addr" toml:"capture_addr"`
+	ManagementAddr       string   `json:"management_addr" yaml:"management_addr" toml:"management_addr"`
+	BaseURL              string   `json:"base_url" yaml:"base_url" toml:"base_url"`             // ohne Angabe http://localhost mit dem Port des Capture-Servers
+	DataDir              string   `json:"data_dir" yaml:"data_dir" toml:"data_dir"`             // enthält alle gespeicherten Daten
+	RequestsDir          string   `json:"requests_dir" yaml:"requests_dir" toml:"requests_dir"` // relativ zu data_dir, falls nicht absolut
+	StaticFilesDir       string   `json:"static_files_dir" yaml:"static_files_dir" toml:"static_files_dir"`
+	PageSize             int      `json:"page_size" yaml:"page_size" toml:"page_size"`
+	AdminToken           string   `json:"admin_token" yaml:"admin_token" toml:"admin_token"`
+	EncryptionKey        string   `json:"encryption_key" yaml:"encryption_key" toml:"encryption_key"`
+	EncryptionKeyFile    string   `json:"encryption_key_file" yaml:"encryption_key_file" toml:"encryption_key_file"`
+	SSEQueueSize         int      `json:"sse_queue_size" yaml:"sse_queue_size" toml:"sse_queue_size"`
+	SSEOverflowPolicy    string   `json:"sse_overflow_policy" yaml:"sse_overflow_policy" toml:"sse_overflow_policy"`
+	RetentionMaxRequests int      `json:"retention_max_requests" yaml:"retention_max_requests" toml:"retention_max_requests"`
+	RetentionMaxAge      Duration `json:"retention_max_age" yaml:"retention_max_age" toml:"retention_max_age"`
+}
+
+// Eine Dauer, die in der Datei und in /config als Text wie "72h" steht
+type Duration time.Duration
+
+func (d Duration) String() string { return time.Duration(d).String() }
+
+func (d Duration) MarshalText() ([]byte, error) { return []byte(d.String()), nil }
+
+func (d *Duration) UnmarshalText(text []byte) error { return d.Set(string(text)) }
+
+// Set erfüllt flag.Value, damit eine Dauer wie die übrigen Werte aus Umgebung und Kommandozeile gelesen wird
+func (d *Duration) Set(value string) error {
+	v, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("keine Dauer wie \"72h\": %q", value)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Die geladene Konfiguration. Main ersetzt sie durch das Ergebnis von LoadConfig, NewServer zusätzlich data_dir.
+var config = defaultConfig().withBaseURL()
+
+// Die Standardwerte entsprechen dem bisherigen Verhalten des Programms
+func defaultConfig() Config {
+	return Config{
+		CaptureAddr:       ":8080",
+		ManagementAddr:    ":8081",
+		DataDir:           ".",
+		RequestsDir:       "./requests",
+		StaticFilesDir:    "./static-files",
+		PageSize:          10,
+		SSEQueueSize:      100,
+		SSEOverflowPolicy: overflowDropOldest,
+	}
+}
+
+// Umgebungsvariable und Option mit dem Pfad der Konfigurationsdatei
+const configFileEnv = "CONFIG_FILE"
+
+// Ein Wert der Konfiguration mit Umgebungsvariable und Option.
+// Geheimnisse gibt es nicht als Option, damit sie nicht in der Prozessliste stehen, und /config zeigt sie maskiert.
+type configOption struct {
+	key    string // Schlüssel in der Datei, als Option mit "-" statt "_"
+	env    string
+	usage  string
+	secret bool
+	field  func(c *Config) interface{} // Zeiger auf das Feld: *string, *int oder flag.Value
+}
+
+var configOptions = []configOption{
+	{key: "capture_addr", env: "CAPTURE_ADDR", usage: "Adresse des Capture-Servers", field: func(c *Config) interface{} { return &c.CaptureAddr }},
+	{key: "management_addr", env: "MANAGEMENT_ADDR", usage: "Adresse der Management-API", field: func(c *Config) interface{} { return &c.ManagementAddr }},
+	{key: "base_url", env: "BASE_URL", usage: "Adresse, unter der der Capture-Server von außen erreichbar ist", field: func(c *Config) interface{} { return &c.BaseURL }},
+	{key: "data_dir", env: "DATA_DIR", usage: "Verzeichnis für alle gespeicherten Daten", field: func(c *Config) interface{} { return &c.DataDir }},
+	{key: "requests_dir", env: "REQUESTS_DIR", usage: "Verzeichnis der gespeicherten Anfragen, relativ zu data_dir", field: func(c *Config) interface{} { return &c.RequestsDir }},
+	{key: "static_files_dir", env: "STATIC_FILES_DIR", usage: "Verzeichnis der gespeicherten Bodies, relativ zu data_dir", field: func(c *Config) interface{} { return &c.StaticFilesDir }},
+	{key: "page_size", env: "PAGE_SIZE", usage: "Anfragen je Seite von /view-requests", field: func(c *Config) interface{} { return &c.PageSize }},
+	{key: "admin_token", env: "ADMIN_TOKEN", usage: "zusätzliches Admin-Token, das nur im Speicher gehalten wird", secret: true, field: func(c *Config) interface{} { return &c.AdminToken }},
+	{key: "encryption_key", env: "ENCRYPTION_KEY", usage: "AES-256-Schlüssel, 32 Bytes Base64-kodiert", secret: true, field: func(c *Config) interface{} { return &c.EncryptionKey }},
+	{key: "encryption_key_file", env: "ENCRYPTION_KEY_FILE", usage: "Datei mit dem AES-256-Schlüssel", field: func(c *Config) interface{} { return &c.EncryptionKeyFile }},
+	{key: "sse_queue_size", env: "SSE_QUEUE_SIZE", usage: "gepufferte Nachrichten je SSE-Client", field: func(c *Config) interface{} { return &c.SSEQueueSize }},
+	{key: "sse_overflow_policy", env: "SSE_OVERFLOW_POLICY", usage: "Verhalten bei voller Warteschlange: drop-oldest, drop-new oder disconnect", field: func(c *Config) interface{} { return &c.SSEOverflowPolicy }},
+	{key: "retention_max_requests", env: "RETENTION_MAX_REQUESTS", usage: "höchstens so viele Anfragen je Bin aufbewahren, 0 für unbegrenzt", field: func(c *Config) interface{} { return &c.RetentionMaxRequests }},
+	{key: "retention_max_age", env: "RETENTION_MAX_AGE", usage: "Anfragen höchstens so lange aufbewahren, z. B. 72h, 0s für unbegrenzt", field: func(c *Config) interface{} { return &c.RetentionMaxAge }},
+}
+
+// Bindet eine Option an das Feld der Konfiguration
+func bindConfigOption(fs *flag.FlagSet, name string, opt configOption, c *Config) {
+	switch p := opt.field(c).(type) {
+	case *string:
+		fs.StringVar(p, name, *p, opt.usage)
+	case *int:
+		fs.IntVar(p, name, *p, opt.usage)
+	case flag.Value:
+		fs.Var(p, name, opt.usage)
+	}
+}
+
+// Lädt die Konfiguration aus Datei, Umgebung und den Optionen in args, z. B. den Argumenten von "serve",
+// und prüft sie. Mit -h wird die Hilfe ausgegeben und flag.ErrHelp geliefert.
+func LoadConfig(args []string) (Config, error) {
+	cfg := defaultConfig()
+
+	// Beide Sätze schreiben direkt in cfg. env dient nur zum Lesen der Umgebungsvariablen mit denselben Typen.
+	env := flag.NewFlagSet("env", flag.ContinueOnError)
+	cli := flag.NewFlagSet("serve", flag.ContinueOnError)
+	cli.Usage = func() {
+		fmt.Fprintln(cli.Output(), "Verwendung: inspector serve [Optionen]\n\n"+
+			"Jede Ebene überschreibt die vorherige: Standardwerte, Konfigurationsdatei, Umgebungsvariablen, Optionen.\n\nOptionen:")
+		cli.PrintDefaults()
+	}
+	file := cli.String("config", os.Getenv(configFileEnv), "Konfigurationsdatei, YAML oder TOML ($"+configFileEnv+")")
+	for _, opt := range configOptions {
+		bindConfigOption(env, opt.key, opt, &cfg)
+		if !opt.secret {
+			bindConfigOption(cli, strings.ReplaceAll(opt.key, "_", "-"), opt, &cfg)
+		}
+	}
+	if err := cli.Parse(args); err != nil {
+		return cfg, err
+	}
+	if cli.NArg() > 0 {
+		return cfg, fmt.Errorf("unerwartetes Argument %q", cli.Arg(0))
+	}
+
+	// Die Optionen gelten zuletzt, daher werden sie gemerkt und nach Datei und Umgebung erneut gesetzt
+	flags := make(map[string]string)
+	cli.Visit(func(f *flag.Flag) {
+		if f.Name != "config" {
+			flags[f.Name] = f.Value.String()
+		}
+	})
+	cfg = defaultConfig()
+
+	if *file != "" {
+		if err := cfg.loadFile(*file); err != nil {
+			return cfg, err
+		}
+	}
+	for _, opt := range configOptions {
+		if value := os.Getenv(opt.env); value != "" {
+			if err := env.Set(opt.key, value); err != nil {
+				return cfg, fmt.Errorf("%s: ungültiger Wert %q", opt.env, value)
+			}
+		}
+	}
+	for name, value := range flags {
+		if err := cli.Set(name, value); err != nil {
+			return cfg, err
+		}
+	}
+
+	cfg = cfg.withBaseURL()
+	return cfg, cfg.validate()
+}
+
+// Liest eine YAML- oder TOML-Datei, das Format ergibt sich aus der Endung. Unbekannte Schlüssel sind ein Fehler.
+func (c *Config) loadFile(name string) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("Konfigurationsdatei nicht lesbar: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(c)
+		if errors.Is(err, io.EOF) {
+			// Eine leere Datei ändert nichts
+			err = nil
+		}
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(c)
+		var strict *toml.StrictMissingError
+		if errors.As(err, &strict) {
+			// Die Meldung allein nennt die unbekannten Schlüssel nicht
+			err = errors.New(strings.TrimSpace(strict.String()))
+		}
+	default:
+		return fmt.Errorf("%s: unbekanntes Format, erwartet .yaml, .yml oder .toml", name)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// Ohne base_url ist der Capture-Server unter localhost mit seinem Port erreichbar
+func (c Config) withBaseURL() Config {
+	if c.BaseURL == "" {
+		if host, port, err := net.SplitHostPort(c.CaptureAddr); err == nil {
+			if host == "" || host == "0.0.0.0" || host == "::" {
+				host = "localhost"
+			}
+			c.BaseURL = "http://" + net.JoinHostPort(host, port)
+		}
+	}
+	c.BaseURL = strings.TrimSuffix(c.BaseURL, "/")
+	return c
+}
+
+// Prüft alle Werte und liefert sämtliche Fehler auf einmal
+func (c Config) validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	checkAddr := func(key, addr string) {
+		_, port, err := net.SplitHostPort(addr)
+		n, portErr := strconv.Atoi(port)
+		check(err == nil && portErr == nil && n >= 0 && n <= 65535, "%s muss eine Adresse wie \":8080\" sein, nicht %q", key, addr)
+	}
+	checkAddr("capture_addr", c.CaptureAddr)
+	checkAddr("management_addr", c.ManagementAddr)
+	check(c.CaptureAddr != c.ManagementAddr, "capture_addr und management_addr müssen verschieden sein")
+
+	u, err := url.Parse(c.BaseURL)
+	check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "base_url muss eine http- oder https-URL sein, nicht %q", c.BaseURL)
+
+	check(c.DataDir != "", "data_dir darf nicht leer sein")
+	check(c.RequestsDir != "", "requests_dir darf nicht leer sein")
+	check(c.StaticFilesDir != "", "static_files_dir darf nicht leer sein")
+	check(filepath.Clean(c.RequestsDir) != filepath.Clean(c.StaticFilesDir), "requests_dir und static_files_dir müssen verschieden sein")
+	check(c.PageSize >= 1 && c.PageSize <= 1000, "page_size muss zwischen 1 und 1000 liegen")
+
+	check(c.EncryptionKey == "" || c.EncryptionKeyFile == "", "encryption_key und encryption_key_file schließen sich aus")
+	check(c.SSEQueueSize > 0, "sse_queue_size muss eine positive Zahl sein")
+	switch c.SSEOverflowPolicy {
+	case overflowDropOldest, overflowDropNew, overflowDisconnect:
+	default:
+		check(false, "sse_overflow_policy muss %s, %s oder %s sein", overflowDropOldest, overflowDropNew, overflowDisconnect)
+	}
+	check(c.RetentionMaxRequests >= 0, "retention_max_requests darf nicht negativ sein")
+	check(c.RetentionMaxAge >= 0, "retention_max_age darf nicht negativ sein")
+
+	return errors.Join(errs...)
+}
+
+// Liefert einen Pfad im Datenverzeichnis, z. B. dataPath("tokens", id+".json").
+// Absolute Pfade wie ein eigenes requests_dir bleiben unverändert.
+func dataPath(elem ...string) string {
+	p := filepath.Join(elem...)
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(config.DataDir, p)
+}
+
+// Ersatz für Geheimnisse in /config
+const maskedSecret = "********"
+
+// Die Konfiguration mit maskierten Geheimnissen. Leere Werte bleiben leer, damit erkennbar ist, ob einer gesetzt ist.
+func (c Config) masked() Config {
+	for _, opt := range configOptions {
+		if p, ok := opt.field(&c).(*string); ok && opt.secret && *p != "" {
+			*p = maskedSecret
+		}
+	}
+	return c
+}
+
+// Gibt die wirksame Konfiguration aus (nur für Admins)
+func viewConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, config.masked())
+}
@@ -0,0 +1,97 @@
+package inspector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	yamlFile := writeFile("config.yaml", "page_size: 20\nretention_max_age: 72h\nsse_overflow_policy: drop-new\n")
+	tomlFile := writeFile("config.toml", "page_size = 25\ncapture_addr = \":9000\"\n")
+	unknownFile := writeFile("unbekannt.yaml", "page_sice: 20\n")
+	emptyFile := writeFile("leer.yaml", "")
+	iniFile := writeFile("config.ini", "page_size=20\n")
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		check   func(c Config) bool
+		wantErr string
+	}{
+		{"Standardwerte", nil, nil, func(c Config) bool {
+			return c.PageSize == 10 && c.SSEOverflowPolicy == overflowDropOldest && c.BaseURL == "http://localhost:8080"
+		}, ""},
+		{"Datei", nil, []string{"--config", yamlFile}, func(c Config) bool {
+			return c.PageSize == 20 && c.RetentionMaxAge == Duration(72*time.Hour) && c.SSEOverflowPolicy == overflowDropNew
+		}, ""},
+		{"Datei aus CONFIG_FILE", map[string]string{configFileEnv: yamlFile}, nil, func(c Config) bool { return c.PageSize == 20 }, ""},
+		{"TOML", nil, []string{"--config", tomlFile}, func(c Config) bool {
+			return c.PageSize == 25 && c.BaseURL == "http://localhost:9000"
+		}, ""},
+		{"leere Datei", nil, []string{"--config", emptyFile}, func(c Config) bool { return c.PageSize == 10 }, ""},
+		{"Umgebung vor Datei", map[string]string{"PAGE_SIZE": "30"}, []string{"--config", yamlFile}, func(c Config) bool {
+			return c.PageSize == 30 && c.SSEOverflowPolicy == overflowDropNew
+		}, ""},
+		{"Option vor Umgebung", map[string]string{"PAGE_SIZE": "30", "RETENTION_MAX_AGE": "1h"}, []string{"--page-size", "40", "--config", yamlFile}, func(c Config) bool {
+			return c.PageSize == 40 && c.RetentionMaxAge == Duration(time.Hour)
+		}, ""},
+		{"Geheimnis nur aus der Umgebung", map[string]string{"ADMIN_TOKEN": "geheim"}, nil, func(c Config) bool { return c.AdminToken == "geheim" }, ""},
+		{"Geheimnis als Option", nil, []string{"--admin-token", "geheim"}, nil, "admin-token"},
+		{"unbekannter Schlüssel", nil, []string{"--config", unknownFile}, nil, "page_sice"},
+		{"unbekanntes Format", nil, []string{"--config", iniFile}, nil, "unbekanntes Format"},
+		{"Datei fehlt", nil, []string{"--config", filepath.Join(dir, "fehlt.yaml")}, nil, "nicht lesbar"},
+		{"ungültige Umgebungsvariable", map[string]string{"PAGE_SIZE": "zehn"}, nil, nil, "PAGE_SIZE"},
+		{"Argument", nil, []string{"los"}, nil, "unerwartetes Argument"},
+		{"alle Prüffehler", map[string]string{"PAGE_SIZE": "0", "SSE_OVERFLOW_POLICY": "block"}, nil, nil, "page_size muss zwischen 1 und 1000 liegen\nsse_overflow_policy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(configFileEnv, "")
+			for _, opt := range configOptions {
+				t.Setenv(opt.env, "")
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := LoadConfig(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Fehler %v, erwartet %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.check(cfg) {
+				t.Errorf("unerwartete Konfiguration %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestConfigMasked(t *testing.T) {
+	c := defaultConfig()
+	c.AdminToken, c.EncryptionKeyFile = "geheim", "/etc/inspector/key"
+	masked := c.masked()
+	if masked.AdminToken != maskedSecret || masked.EncryptionKey != "" || masked.EncryptionKeyFile != c.EncryptionKeyFile {
+		t.Errorf("maskiert %+v", masked)
+	}
+	if c.AdminToken != "geheim" {
+		t.Error("Original verändert")
+	}
+}
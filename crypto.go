@@ -0,0 +1,213 @@
+package inspector
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Umgebungsvariablen, über die beim Befehl "reencrypt" der neue AES-256-Schlüssel (32 Bytes, Base64-kodiert) angegeben wird.
+// NEW_ENCRYPTION_KEY enthält den Schlüssel direkt, NEW_ENCRYPTION_KEY_FILE den Pfad zu einer Datei mit dem Schlüssel.
+// Der bisherige Schlüssel stammt aus encryption_key bzw. encryption_key_file der Konfiguration.
+const (
+	newEncryptionKeyEnv     = "NEW_ENCRYPTION_KEY"
+	newEncryptionKeyFileEnv = "NEW_ENCRYPTION_KEY_FILE"
+)
+
+// Der geladene Schlüssel, nil wenn keiner konfiguriert ist.
+// Ist ein Schlüssel gesetzt, werden alle gespeicherten Requests, Bodies und Mock-Regeln verschlüsselt abgelegt.
+var encryptionKey []byte
+
+// Kennzeichnet verschlüsselt gespeicherte Dateien, damit Klartextdateien weiterhin gelesen werden können
+var encryptedFileMagic = []byte("HRENC1\n")
+
+// Endung der Zwischendateien von "reencrypt". Beim Laden werden sie übersprungen, da ein abgebrochener Lauf sie
+// neben den gespeicherten Dateien zurücklassen kann.
+const reencryptTempSuffix = ".tmp"
+
+// Verzeichnisse, deren Dateien verschlüsselt gespeichert werden
+func encryptedDirectories() []string {
+	return []string{dataPath(config.RequestsDir), dataPath(config.StaticFilesDir), dataPath("unredacted"), dataPath("mocks")}
+}
+
+// Lädt den Schlüssel aus encryption_key bzw. encryption_key_file der Konfiguration.
+// Ist keiner der beiden Werte gesetzt, bleibt die Verschlüsselung deaktiviert.
+func loadEncryptionKey() error {
+	key, err := readKey(config.EncryptionKey, config.EncryptionKeyFile)
+	if err != nil {
+		return err
+	}
+	encryptionKey = key
+	return nil
+}
+
+// Liest einen Base64-kodierten 32-Byte-Schlüssel direkt aus value oder aus der Datei path
+func readKey(value, path string) ([]byte, error) {
+	if value == "" && path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Schlüsseldatei nicht lesbar: %w", err)
+		}
+		value = string(data)
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("Schlüssel ist nicht Base64-kodiert: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("Schlüssel muss 32 Bytes lang sein, hat aber %d", len(key))
+	}
+	return key, nil
+}
+
+// Verschlüsselt data mit AES-GCM. Das Ergebnis besteht aus Nonce und Ciphertext.
+func encrypt(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// Entschlüsselt die von encrypt erzeugten Daten
+func decrypt(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("verschlüsselte Daten sind zu kurz")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if key == nil {
+		return nil, errors.New("kein Schlüssel konfiguriert")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Schreibt eine Datei. Ist ein Schlüssel konfiguriert, wird der Inhalt verschlüsselt.
+func writeStoredFile(path string, data []byte) error {
+	return writeStoredFileWithKey(path, data, encryptionKey)
+}
+
+// Liest eine mit writeStoredFile geschriebene Datei und entschlüsselt sie bei Bedarf
+func readStoredFile(path string) ([]byte, error) {
+	return readStoredFileWithKey(path, encryptionKey)
+}
+
+func writeStoredFileWithKey(path string, data, key []byte) error {
+	if key != nil {
+		encrypted, err := encrypt(key, data)
+		if err != nil {
+			return err
+		}
+		data = append(append([]byte{}, encryptedFileMagic...), encrypted...)
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func readStoredFileWithKey(path string, key []byte) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, encryptedFileMagic) {
+		return data, nil
+	}
+	return decrypt(key, data[len(encryptedFileMagic):])
+}
+
+// Befehl "reencrypt": Entschlüsselt alle gespeicherten Dateien mit dem aktuellen Schlüssel
+// und verschlüsselt sie mit dem neuen Schlüssel aus NEW_ENCRYPTION_KEY bzw. NEW_ENCRYPTION_KEY_FILE.
+// Ohne neuen Schlüssel werden die Dateien im Klartext gespeichert, ohne alten Schlüssel werden Klartextdateien verschlüsselt.
+// Bricht ein Lauf ab, kann er mit denselben Schlüsseln wiederholt werden: Dateien, die sich bereits mit dem neuen
+// Schlüssel lesen lassen, bleiben unverändert.
+func reencryptStorage() error {
+	newKey, err := readKey(os.Getenv(newEncryptionKeyEnv), os.Getenv(newEncryptionKeyFileEnv))
+	if err != nil {
+		return err
+	}
+
+	// Ungeschwärzte Kopien dürfen nie im Klartext gespeichert werden
+	if newKey == nil {
+		if entries, _ := os.ReadDir(dataPath("unredacted")); len(entries) > 0 {
+			return errors.New("ungeschwärzte Kopien vorhanden, ein neuer Schlüssel ist erforderlich")
+		}
+	}
+
+	count, skipped := 0, 0
+	for _, dir := range encryptedDirectories() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if strings.HasSuffix(path, reencryptTempSuffix) {
+				// Rest eines abgebrochenen Laufs, die Datei selbst wurde nicht ersetzt
+				if err := os.Remove(path); err != nil {
+					return err
+				}
+				continue
+			}
+
+			data, err := readStoredFileWithKey(path, encryptionKey)
+			if err != nil {
+				// Bereits bei einem früheren, abgebrochenen Lauf umgeschlüsselt
+				if newKey != nil {
+					if _, newErr := readStoredFileWithKey(path, newKey); newErr == nil {
+						skipped++
+						continue
+					}
+				}
+				return fmt.Errorf("%s: %w", path, err)
+			}
+
+			// Erst in eine temporäre Datei schreiben und dann umbenennen, damit keine halben Dateien entstehen
+			tmp := path + reencryptTempSuffix
+			if err := writeStoredFileWithKey(tmp, data, newKey); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if err := os.Rename(tmp, path); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			count++
+		}
+	}
+
+	log.Printf("%d Dateien neu verschlüsselt, %d waren bereits mit dem neuen Schlüssel verschlüsselt\n", count, skipped)
+	return nil
+}
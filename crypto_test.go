@@ -0,0 +1,216 @@
+package inspector
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestReadKey(t *testing.T) {
+	valid := base64.StdEncoding.EncodeToString(testKey(1))
+	file := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(file, []byte(valid+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		path    string
+		want    []byte
+		wantErr string
+	}{
+		{"kein Schlüssel", "", "", nil, ""},
+		{"direkt", valid, "", testKey(1), ""},
+		{"aus Datei", "", file, testKey(1), ""},
+		{"Wert vor Datei", valid, "/gibt/es/nicht", testKey(1), ""},
+		{"Datei fehlt", "", "/gibt/es/nicht", nil, "nicht lesbar"},
+		{"kein Base64", "%%%", "", nil, "nicht Base64"},
+		{"zu kurz", base64.StdEncoding.EncodeToString([]byte("kurz")), "", nil, "32 Bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readKey(tt.value, tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Fehler %v, erwartet %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("Schlüssel %x, erwartet %x", got, tt.want)
+			}
+		})
+	}
+}
+
+// Verschlüsselte Dateien bestehen aus der Kennung, der 12-Byte-Nonce und dem AES-GCM-Ciphertext samt 16-Byte-Tag
+func TestStoredFileFormat(t *testing.T) {
+	plain := []byte(`{"id":"1"}`)
+	tests := []struct {
+		name    string
+		key     []byte
+		readKey []byte
+		wantLen int // Länge der Datei
+		wantErr bool
+	}{
+		{"Klartext", nil, nil, len(plain), false},
+		{"Klartext mit Schlüssel lesen", nil, testKey(1), len(plain), false},
+		{"verschlüsselt", testKey(1), testKey(1), len(encryptedFileMagic) + 12 + len(plain) + 16, false},
+		{"falscher Schlüssel", testKey(1), testKey(2), len(encryptedFileMagic) + 12 + len(plain) + 16, true},
+		{"ohne Schlüssel lesen", testKey(1), nil, len(encryptedFileMagic) + 12 + len(plain) + 16, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "datei.json")
+			if err := writeStoredFileWithKey(path, plain, tt.key); err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0600 {
+				t.Errorf("Berechtigungen %v, erwartet 0600", info.Mode().Perm())
+			}
+			raw, _ := os.ReadFile(path)
+			if len(raw) != tt.wantLen {
+				t.Errorf("Datei hat %d Bytes, erwartet %d", len(raw), tt.wantLen)
+			}
+			if encrypted := bytes.HasPrefix(raw, encryptedFileMagic); encrypted != (tt.key != nil) {
+				t.Errorf("Kennung vorhanden: %v", encrypted)
+			}
+
+			got, err := readStoredFileWithKey(path, tt.readKey)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("kein Fehler, Inhalt %q", got)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(got, plain) {
+				t.Errorf("gelesen %q (%v), erwartet %q", got, err, plain)
+			}
+		})
+	}
+}
+
+func TestReencryptStorage(t *testing.T) {
+	saved, savedKey := config, encryptionKey
+	t.Cleanup(func() {
+		config, encryptionKey = saved, savedKey
+	})
+	config = defaultConfig()
+	config.DataDir = t.TempDir()
+
+	// Je eine Klartextdatei in jedem verschlüsselten Verzeichnis
+	files := map[string][]byte{}
+	for i, dir := range encryptedDirectories() {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, "datei.json")
+		files[path] = []byte{'{', byte('a' + i), '}'}
+		if err := writeStoredFileWithKey(path, files[path], nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Ein abgebrochener Lauf von Schlüssel 2 zu 4: eine Datei ist umgeschlüsselt, eine Zwischendatei liegt noch herum
+	interrupted := func(t *testing.T) {
+		path := filepath.Join(dataPath(config.RequestsDir), "datei.json")
+		if err := writeStoredFileWithKey(path, files[path], testKey(4)); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path+reencryptTempSuffix, []byte("halb"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps := []struct {
+		name    string
+		prepare func(t *testing.T)
+		oldKey  []byte
+		newKey  []byte
+		wantErr string
+	}{
+		{"Klartext verschlüsseln", nil, nil, testKey(1), ""},
+		{"Schlüssel wechseln", nil, testKey(1), testKey(2), ""},
+		{"abgebrochenen Lauf wiederholen", interrupted, testKey(2), testKey(4), ""},
+		{"falscher alter Schlüssel", nil, testKey(1), testKey(3), "datei.json"},
+		{"Klartext trotz ungeschwärzter Kopien", nil, testKey(4), nil, "neuer Schlüssel"},
+	}
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			if step.prepare != nil {
+				step.prepare(t)
+			}
+			encryptionKey = step.oldKey
+			t.Setenv(newEncryptionKeyEnv, "")
+			if step.newKey != nil {
+				t.Setenv(newEncryptionKeyEnv, base64.StdEncoding.EncodeToString(step.newKey))
+			}
+
+			err := reencryptStorage()
+			if step.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), step.wantErr) {
+					t.Fatalf("Fehler %v, erwartet %q", err, step.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for path, want := range files {
+				got, err := readStoredFileWithKey(path, step.newKey)
+				if err != nil || !bytes.Equal(got, want) {
+					t.Errorf("%s: %q (%v), erwartet %q", path, got, err, want)
+				}
+				if _, err := os.Stat(path + reencryptTempSuffix); !os.IsNotExist(err) {
+					t.Errorf("%s.tmp nicht entfernt", path)
+				}
+			}
+		})
+	}
+}
+
+// Eine Zwischendatei eines abgebrochenen Laufs wird nicht als zweite Anfrage geladen
+func TestRestoreRequestsSkipsTempFiles(t *testing.T) {
+	saved := config
+	t.Cleanup(func() {
+		config = saved
+		resetState()
+	})
+	resetState()
+	config.DataDir = t.TempDir()
+	if err := createRequestsDirectory(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := dataPath(config.RequestsDir, "eins.json")
+	data := []byte(`{"id":"eins","bin":"default"}`)
+	for _, name := range []string{path, path + reencryptTempSuffix} {
+		if err := os.WriteFile(name, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	restoreRequests()
+	requestsMu.RLock()
+	n := len(requests)
+	requestsMu.RUnlock()
+	if n != 1 {
+		t.Errorf("%d Anfragen geladen, erwartet 1", n)
+	}
+}
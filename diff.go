@@ -0,0 +1,422 @@
+package inspector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Art einer Änderung zwischen zwei Anfragen
+const (
+	changeAdded   = "added"
+	changeRemoved = "removed"
+	changeChanged = "changed"
+)
+
+// Eine Änderung an einem Header, Query-Parameter, Formularfeld oder JSON-Pfad.
+// Bei mehrfach vorkommenden Headern und Parametern werden die Werte mit ", " verbunden.
+type FieldChange struct {
+	Name string      `json:"name"`
+	Type string      `json:"type"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Ein geänderter Einzelwert wie Methode oder Pfad
+type ValueChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// Vergleich der Bodies über ihren SHA-256-Hash
+type BodyComparison struct {
+	Kind  string `json:"kind"` // json, form, text, binary oder none
+	Equal bool   `json:"equal"`
+	HashA string `json:"hash_a,omitempty"`
+	HashB string `json:"hash_b,omitempty"`
+	SizeA int    `json:"size_a"`
+	SizeB int    `json:"size_b"`
+}
+
+// Der strukturierte Unterschied zwischen zwei gespeicherten Anfragen
+type RequestDiff struct {
+	A       string         `json:"a"`
+	B       string         `json:"b"`
+	Equal   bool           `json:"equal"`
+	Method  *ValueChange   `json:"method,omitempty"`
+	Path    *ValueChange   `json:"path,omitempty"`
+	Headers []FieldChange  `json:"headers"`
+	Query   []FieldChange  `json:"query"`
+	Form    []FieldChange  `json:"form"`
+	JSON    []FieldChange  `json:"json"`
+	Body    BodyComparison `json:"body"`
+}
+
+// Vergleicht zwei Maps von Werten und liefert die Änderungen nach Namen sortiert
+func diffValues(a, b map[string]string) []FieldChange {
+	changes := []FieldChange{}
+	for name, old := range a {
+		if value, ok := b[name]; !ok {
+			changes = append(changes, FieldChange{Name: name, Type: changeRemoved, Old: old})
+		} else if value != old {
+			changes = append(changes, FieldChange{Name: name, Type: changeChanged, Old: old, New: value})
+		}
+	}
+	for name, value := range b {
+		if _, ok := a[name]; !ok {
+			changes = append(changes, FieldChange{Name: name, Type: changeAdded, New: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+// Fasst mehrfach vorkommende Werte für den Vergleich zusammen
+func joinValues(values map[string][]string) map[string]string {
+	joined := make(map[string]string, len(values))
+	for name, v := range values {
+		joined[name] = strings.Join(v, ", ")
+	}
+	return joined
+}
+
+// Vergleicht zwei JSON-Dokumente rekursiv. Die Pfade werden als JSON Pointer angegeben.
+func diffJSON(pointer string, a, b interface{}, changes []FieldChange) []FieldChange {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(x)+len(y))
+		for key := range x {
+			keys = append(keys, key)
+		}
+		for key := range y {
+			if _, ok := x[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			child := pointer + "/" + escapePointer(key)
+			old, inA := x[key]
+			value, inB := y[key]
+			switch {
+			case !inB:
+				changes = append(changes, FieldChange{Name: child, Type: changeRemoved, Old: old})
+			case !inA:
+				changes = append(changes, FieldChange{Name: child, Type: changeAdded, New: value})
+			default:
+				changes = diffJSON(child, old, value, changes)
+			}
+		}
+		return changes
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(x) || i < len(y); i++ {
+			child := pointer + "/" + strconv.Itoa(i)
+			switch {
+			case i >= len(y):
+				changes = append(changes, FieldChange{Name: child, Type: changeRemoved, Old: x[i]})
+			case i >= len(x):
+				changes = append(changes, FieldChange{Name: child, Type: changeAdded, New: y[i]})
+			default:
+				changes = diffJSON(child, x[i], y[i], changes)
+			}
+		}
+		return changes
+	}
+
+	if !jsonEqual(a, b) {
+		name := pointer
+		if name == "" {
+			name = "/"
+		}
+		changes = append(changes, FieldChange{Name: name, Type: changeChanged, Old: a, New: b})
+	}
+	return changes
+}
+
+// Liest den Body einer Anfrage. Formulare werden wie in den Snippets URL-kodiert.
+func diffBody(r Request) []byte {
+	if len(r.BodyParams) > 0 {
+		form := url.Values{}
+		for key, value := range r.BodyParams {
+			form.Set(key, value)
+		}
+		return []byte(form.Encode())
+	}
+	body, err := readRequestBody(r)
+	if err != nil {
+		log.Println("Fehler beim Lesen des Request-Body:", err)
+	}
+	return body
+}
+
+func bodyHash(body []byte) string {
+	if body == nil {
+		return ""
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// Bestimmt die Art eines Bodies für den Vergleich
+func bodyKind(r Request, body []byte) string {
+	switch {
+	case len(r.BodyParams) > 0:
+		return "form"
+	case len(body) == 0:
+		return "none"
+	case json.Valid(body):
+		return "json"
+	case utf8.Valid(body):
+		return "text"
+	}
+	return "binary"
+}
+
+// Vergleicht zwei gespeicherte Anfragen
+func diffRequests(a, b Request) RequestDiff {
+	d := RequestDiff{A: a.ID, B: b.ID}
+
+	if a.Method != b.Method {
+		d.Method = &ValueChange{Old: a.Method, New: b.Method}
+	}
+
+	ua, _ := url.Parse(a.URL)
+	ub, _ := url.Parse(b.URL)
+	if ua == nil {
+		ua = &url.URL{}
+	}
+	if ub == nil {
+		ub = &url.URL{}
+	}
+	if ua.Path != ub.Path {
+		d.Path = &ValueChange{Old: ua.Path, New: ub.Path}
+	}
+
+	d.Headers = diffValues(joinValues(a.Headers), joinValues(b.Headers))
+	d.Query = diffValues(joinValues(ua.Query()), joinValues(ub.Query()))
+	d.Form = diffValues(a.BodyParams, b.BodyParams)
+
+	bodyA, bodyB := diffBody(a), diffBody(b)
+	d.Body = BodyComparison{
+		Kind:  bodyKind(a, bodyA),
+		HashA: bodyHash(bodyA),
+		HashB: bodyHash(bodyB),
+		SizeA: len(bodyA),
+		SizeB: len(bodyB),
+	}
+	d.Body.Equal = d.Body.HashA == d.Body.HashB
+	if kindB := bodyKind(b, bodyB); kindB != d.Body.Kind {
+		d.Body.Kind = d.Body.Kind + "/" + kindB
+	}
+
+	d.JSON = []FieldChange{}
+	if d.Body.Kind == "json" && !d.Body.Equal {
+		var docA, docB interface{}
+		if json.Unmarshal(bodyA, &docA) == nil && json.Unmarshal(bodyB, &docB) == nil {
+			d.JSON = diffJSON("", docA, docB, d.JSON)
+		}
+	}
+
+	d.Equal = d.Method == nil && d.Path == nil && len(d.Headers) == 0 && len(d.Query) == 0 &&
+		len(d.Form) == 0 && d.Body.Equal
+	return d
+}
+
+// Stellt eine Anfrage zeilenweise für den Textvergleich dar.
+// JSON-Bodies werden eingerückt, binäre Bodies durch ihren Hash ersetzt.
+func requestLines(r Request) []string {
+	lines := []string{r.Method + " " + r.URL}
+
+	names := make([]string, 0, len(r.Headers))
+	for name := range r.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range r.Headers[name] {
+			lines = append(lines, name+": "+v)
+		}
+	}
+	lines = append(lines, "")
+
+	body := diffBody(r)
+	switch bodyKind(r, body) {
+	case "json":
+		var doc interface{}
+		_ = json.Unmarshal(body, &doc)
+		pretty, _ := json.MarshalIndent(doc, "", "  ")
+		lines = append(lines, strings.Split(string(pretty), "\n")...)
+	case "form", "text":
+		lines = append(lines, strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")...)
+	case "binary":
+		lines = append(lines, fmt.Sprintf("<binär, %d Bytes, sha256 %s>", len(body), bodyHash(body)))
+	}
+	return lines
+}
+
+// Höchstzahl der Zellen der Tabelle für die längste gemeinsame Teilfolge.
+// Bei größeren Unterschieden wird der Diff nur mit den Hashes zusammengefasst.
+const maxDiffCells = 1 << 20
+
+// Eine Zeile eines Diffs
+type diffOp struct {
+	kind byte // ' ', '-' oder '+'
+	line string
+	i, j int // Zeilennummern vor der Operation
+}
+
+// Berechnet die Zeilenoperationen, die a in b überführen. Gemeinsamer Anfang und gemeinsames Ende
+// gehen nicht in die Tabelle ein. Ist der Rest zu groß für maxDiffCells, wird false geliefert.
+func diffLines(a, b []string) ([]diffOp, bool) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if (len(ma)+1)*(len(mb)+1) > maxDiffCells {
+		return nil, false
+	}
+
+	// Längste gemeinsame Teilfolge des geänderten Mittelteils
+	lcs := make([][]int32, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int32, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b)-prefix-suffix)
+	for k := 0; k < prefix; k++ {
+		ops = append(ops, diffOp{' ', a[k], k, k})
+	}
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, diffOp{' ', ma[i], prefix + i, prefix + j})
+			i++
+			j++
+		case i < len(ma) && (j == len(mb) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', ma[i], prefix + i, prefix + j})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', mb[j], prefix + i, prefix + j})
+			j++
+		}
+	}
+	for k := 0; k < suffix; k++ {
+		i, j := len(a)-suffix+k, len(b)-suffix+k
+		ops = append(ops, diffOp{' ', a[i], i, j})
+	}
+	return ops, true
+}
+
+// Erzeugt einen Unified Diff zweier Zeilenlisten mit drei Zeilen Kontext
+func unifiedDiff(nameA, nameB string, a, b []string) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", nameA, nameB)
+
+	ops, ok := diffLines(a, b)
+	if !ok {
+		fmt.Fprintf(&out, "# Unterschied zu groß für einen zeilenweisen Vergleich: %d gegen %d Zeilen\n", len(a), len(b))
+		fmt.Fprintf(&out, "# sha256 %s gegen %s\n", bodyHash([]byte(strings.Join(a, "\n"))), bodyHash([]byte(strings.Join(b, "\n"))))
+		return out.String()
+	}
+
+	const context = 3
+	for start := 0; start < len(ops); {
+		if ops[start].kind == ' ' {
+			start++
+			continue
+		}
+		// Hunk beginnt mit bis zu drei Kontextzeilen und endet, wenn mehr als
+		// doppelt so viele unveränderte Zeilen folgen
+		from := start - context
+		if from < 0 {
+			from = 0
+		}
+		end := start
+		for k := start; k < len(ops); k++ {
+			if ops[k].kind != ' ' {
+				end = k
+			} else if k-end > 2*context {
+				break
+			}
+		}
+		to := end + context + 1
+		if to > len(ops) {
+			to = len(ops)
+		}
+
+		countA, countB := 0, 0
+		for _, o := range ops[from:to] {
+			if o.kind != '+' {
+				countA++
+			}
+			if o.kind != '-' {
+				countB++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", ops[from].i+1, countA, ops[from].j+1, countB)
+		for _, o := range ops[from:to] {
+			out.WriteByte(o.kind)
+			out.WriteString(o.line)
+			out.WriteByte('\n')
+		}
+		start = to
+	}
+	return out.String()
+}
+
+// Vergleicht zwei gespeicherte Anfragen, ?a={id}&b={id}.
+// Mit format=text wird ein Unified Diff ausgegeben, sonst der strukturierte Vergleich als JSON.
+func viewRequestDiff(c *gin.Context) {
+	a, okA := findRequest(c.Query("a"))
+	b, okB := findRequest(c.Query("b"))
+	if !okA || !okB {
+		c.String(http.StatusNotFound, "Anfrage nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, a.Bin, false) || !authorizeBin(c, b.Bin, false) {
+		return
+	}
+
+	if c.Query("format") == "text" {
+		c.String(http.StatusOK, unifiedDiff("a/"+a.ID, "b/"+b.ID, requestLines(a), requestLines(b)))
+		return
+	}
+	c.JSON(http.StatusOK, diffRequests(a, b))
+}
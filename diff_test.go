@@ -0,0 +1,58 @@
+package inspector
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(n int, change map[int]string) []string {
+		list := make([]string, n)
+		for i := range list {
+			list[i] = fmt.Sprint("zeile ", i+1)
+			if s, ok := change[i]; ok {
+				list[i] = s
+			}
+		}
+		return list
+	}
+
+	tests := []struct {
+		name string
+		a, b []string
+		want string
+	}{
+		{"gleich", []string{"x", "y"}, []string{"x", "y"}, ""},
+		{"geändert", []string{"a", "b", "c"}, []string{"a", "B", "c"}, "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n"},
+		{"angehängt", []string{"a"}, []string{"a", "b"}, "@@ -1,1 +1,2 @@\n a\n+b\n"},
+		{"leer", nil, []string{"a"}, "@@ -1,0 +1,1 @@\n+a\n"},
+		{"zwei Hunks", lines(20, nil), lines(20, map[int]string{1: "zwei", 17: "achtzehn"}),
+			"@@ -1,5 +1,5 @@\n zeile 1\n-zeile 2\n+zwei\n zeile 3\n zeile 4\n zeile 5\n" +
+				"@@ -15,6 +15,6 @@\n zeile 15\n zeile 16\n zeile 17\n-zeile 18\n+achtzehn\n zeile 19\n zeile 20\n"},
+		// Gemeinsamer Anfang und gemeinsames Ende zählen nicht zur Grenze
+		{"große Datei, kleine Änderung", lines(50000, nil), lines(50000, map[int]string{25000: "mitte"}),
+			"@@ -24998,7 +24998,7 @@\n zeile 24998\n zeile 24999\n zeile 25000\n-zeile 25001\n+mitte\n zeile 25002\n zeile 25003\n zeile 25004\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unifiedDiff("a", "b", tt.a, tt.b)
+			want := "--- a\n+++ b\n" + tt.want
+			if got != want {
+				t.Errorf("Diff\n%s\nerwartet\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiffTooLarge(t *testing.T) {
+	a, b := make([]string, 3000), make([]string, 3000)
+	for i := range a {
+		a[i], b[i] = fmt.Sprint("a", i), fmt.Sprint("b", i)
+	}
+	got := unifiedDiff("a", "b", a, b)
+	if !strings.Contains(got, "zu groß") || strings.Count(got, "\n") != 4 {
+		t.Errorf("erwartet eine Zusammenfassung, erhalten %d Zeilen:\n%.300s", strings.Count(got, "\n"), got)
+	}
+}
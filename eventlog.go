@@ -0,0 +1,238 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Anzahl der zuletzt gesendeten Ereignisse, die für die Wiederaufnahme per Last-Event-ID vorgehalten werden.
+// Ältere aufgezeichnete Anfragen werden bei Bedarf aus dem Speicher nachgeliefert.
+const sseReplaySize = 1000
+
+// Empfohlene Wartezeit in Millisekunden, bevor ein getrennter Browser sich neu verbindet
+const sseRetryMillis = 3000
+
+// Datei im Datenverzeichnis mit der Obergrenze der reservierten Ereignisnummern.
+// Nummern werden in Blöcken reserviert, damit nicht jedes Ereignis die Datei schreibt. Nach einem Neustart
+// geht es oberhalb der Grenze weiter, die Nummern steigen also auch nach einem Absturz, können aber Lücken haben.
+const eventSequenceFile = "sse-sequence"
+
+// Größe eines reservierten Blocks. Ist die Hälfte verbraucht, wird der nächste im Hintergrund gespeichert.
+const eventSequenceBlock = 1000
+
+// Ein an die SSE-Clients gesendetes Ereignis mit fortlaufender Nummer.
+// Request ist bei Ereignissen zu einer Anfrage gesetzt und wird gegen die Filter der Clients geprüft.
+// Document ist das Body-Dokument der Anfrage, falls HasDocument gesetzt ist, siehe loadDocument.
+type sseEvent struct {
+	ID          uint64
+	Bin         string
+	Name        string
+	Data        []byte
+	Request     *Request
+	Document    interface{}
+	HasDocument bool
+}
+
+// Prüft, ob das Ereignis einen Client mit dem Filter erreichen soll.
+// Ereignisse ohne Anfrage erhalten alle Clients des Bins.
+func (e sseEvent) matches(f requestFilter) bool {
+	if e.Request == nil {
+		return true
+	}
+	if e.HasDocument {
+		return f.matchesMetadata(*e.Request) && f.matchesFields(e.Document)
+	}
+	return f.matches(*e.Request)
+}
+
+// Liest das Body-Dokument der Anfrage einmal vor der Verteilung, falls ein Client des Bins nach Feldern im Body filtert.
+// So wird der Body weder für jeden Client noch unter eventLogMu und SSEClientsMu gelesen.
+func (e *sseEvent) loadDocument() {
+	if e.Request == nil || !clientsFilterFields(e.Bin) {
+		return
+	}
+	e.Document, _ = requestDocument(*e.Request)
+	e.HasDocument = true
+}
+
+// Prüft, ob ein Client des Bins nach Feldern im Body filtert
+func clientsFilterFields(bin string) bool {
+	SSEClientsMu.RLock()
+	defer SSEClientsMu.RUnlock()
+	for _, client := range SSEClients {
+		if (bin == "" || client.bin == bin) && len(client.filter.Fields) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Formatiert das Ereignis im Format von Server-Sent Events
+func (e sseEvent) format() string {
+	return fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Name, e.Data)
+}
+
+// Die Nummer des letzten Ereignisses und die zuletzt gesendeten Ereignisse, älteste zuerst.
+// eventLogMu wird vor SSEClientsMu gesperrt.
+var (
+	eventSeq         uint64
+	eventSeqReserved uint64 // die zuletzt reservierte Obergrenze
+	eventLog         []sseEvent
+	eventLogMu       sync.Mutex
+)
+
+// Serialisiert das Schreiben der Obergrenze, damit ein älterer Block keinen neueren überschreibt
+var (
+	eventSeqWritten uint64
+	eventSeqFileMu  sync.Mutex
+)
+
+// Setzt die Nummerierung oberhalb der gespeicherten Obergrenze fort. Die Nummern gespeicherter Anfragen werden ebenfalls berücksichtigt,
+// falls die Datei fehlt oder veraltet ist. Muss nach restoreRequests aufgerufen werden.
+func restoreEventSequence() {
+	eventLogMu.Lock()
+	defer eventLogMu.Unlock()
+
+	if data, err := os.ReadFile(dataPath(eventSequenceFile)); err == nil {
+		if n, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64); err == nil {
+			eventSeq = n
+		} else {
+			log.Println("Fehler beim Lesen der Ereignisnummer:", err)
+		}
+	} else if !os.IsNotExist(err) {
+		log.Println("Fehler beim Lesen der Ereignisnummer:", err)
+	}
+
+	requestsMu.RLock()
+	for _, r := range requests {
+		if r.Seq > eventSeq {
+			eventSeq = r.Seq
+		}
+	}
+	requestsMu.RUnlock()
+
+	// Der erste Block wird vor dem ersten Ereignis gespeichert
+	eventSeqReserved = eventSeq + eventSequenceBlock
+	saveEventSequence(dataPath(eventSequenceFile), eventSeqReserved)
+}
+
+// Vergibt die nächste Ereignisnummer. Der Aufrufer hält eventLogMu.
+// Die Datei wird nur geschrieben, wenn ein neuer Block reserviert wird, und zwar außerhalb der Sperre.
+func nextEventID() uint64 {
+	eventSeq++
+	if eventSeqReserved < eventSeq+eventSequenceBlock/2 {
+		eventSeqReserved = eventSeq + eventSequenceBlock
+		go saveEventSequence(dataPath(eventSequenceFile), eventSeqReserved)
+	}
+	return eventSeq
+}
+
+// Speichert die Obergrenze der reservierten Nummern, falls sie größer als die zuletzt gespeicherte ist
+func saveEventSequence(name string, reserved uint64) {
+	eventSeqFileMu.Lock()
+	defer eventSeqFileMu.Unlock()
+
+	if reserved <= eventSeqWritten {
+		return
+	}
+	if err := os.WriteFile(name, []byte(strconv.FormatUint(reserved, 10)), 0644); err != nil {
+		log.Println("Fehler beim Speichern der Ereignisnummer:", err)
+		return
+	}
+	eventSeqWritten = reserved
+}
+
+// Nimmt ein Ereignis in den Verlauf auf und hängt es an die Warteschlangen der Clients des Bins,
+// bei einem leeren Bin an die aller Clients.
+// Der Aufrufer hält eventLogMu, damit die Reihenfolge der Nummern auch bei den Clients erhalten bleibt.
+func emitEvent(e sseEvent) {
+	// Der Verlauf behält kein Body-Dokument, damit er mit großen Bodies nicht unnötig Speicher belegt
+	logged := e
+	logged.Document, logged.HasDocument = nil, false
+	eventLog = append(eventLog, logged)
+	if len(eventLog) > sseReplaySize {
+		eventLog = eventLog[len(eventLog)-sseReplaySize:]
+	}
+
+	SSEClientsMu.RLock()
+	clients := make([]SSEClient, 0, len(SSEClients))
+	for _, client := range SSEClients {
+		if (e.Bin == "" || client.bin == e.Bin) && e.matches(client.filter) {
+			clients = append(clients, client)
+		}
+	}
+	SSEClientsMu.RUnlock()
+
+	// Hänge das Ereignis an die Warteschlange jedes Clients an, ohne auf langsame Clients zu warten
+	for _, client := range clients {
+		client.queue.push(e)
+	}
+}
+
+// Liefert die Ereignisse des Bins, die nach lastID gesendet wurden und auf den Filter passen.
+// Was nicht mehr im Verlauf liegt, wird aus den gespeicherten Anfragen als "request.created" nachgeliefert;
+// ältere Änderungen an Anfragen sind dann bereits in deren gespeichertem Stand enthalten.
+// Der Aufrufer hält eventLogMu.
+func missedEvents(bin string, lastID uint64, filter requestFilter) []sseEvent {
+	if lastID >= eventSeq {
+		return nil
+	}
+
+	// Die erste Nummer, die der Verlauf noch abdeckt
+	logStart := eventSeq + 1
+	if len(eventLog) > 0 {
+		logStart = eventLog[0].ID
+	}
+
+	var events []sseEvent
+	if lastID+1 < logStart {
+		var stored []Request
+		requestsMu.RLock()
+		for _, r := range requests {
+			if r.Bin == bin && r.Seq > lastID && r.Seq < logStart && filter.matches(r) {
+				stored = append(stored, r)
+			}
+		}
+		requestsMu.RUnlock()
+
+		sort.Slice(stored, func(i, j int) bool { return stored[i].Seq < stored[j].Seq })
+		for _, r := range stored {
+			data, err := json.Marshal(r)
+			if err != nil {
+				continue
+			}
+			r := r
+			events = append(events, sseEvent{ID: r.Seq, Bin: bin, Name: eventRequestCreated, Data: data, Request: &r})
+		}
+	}
+
+	for _, e := range eventLog {
+		if e.ID > lastID && (e.Bin == "" || e.Bin == bin) && e.matches(filter) {
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// Liest die Nummer des zuletzt empfangenen Ereignisses aus dem Header Last-Event-ID,
+// den EventSource bei der Wiederverbindung selbst setzt, oder aus dem Query-Parameter last_event_id.
+func lastEventID(header, query string) (uint64, bool, error) {
+	value := header
+	if value == "" {
+		value = query
+	}
+	if value == "" {
+		return 0, false, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
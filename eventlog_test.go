@@ -0,0 +1,65 @@
+package inspector
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Nach einem Neustart, auch ohne sauberes Beenden, setzt die Nummerierung oberhalb aller vergebenen Nummern fort
+func TestEventSequenceSurvivesRestart(t *testing.T) {
+	saved := config
+	t.Cleanup(func() {
+		config = saved
+		resetState()
+	})
+	resetState()
+	config.DataDir = t.TempDir()
+
+	readFile := func() uint64 {
+		data, err := os.ReadFile(filepath.Join(config.DataDir, eventSequenceFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	restoreEventSequence()
+	if n := readFile(); n != eventSequenceBlock {
+		t.Fatalf("Obergrenze %d nach dem Start, erwartet %d", n, eventSequenceBlock)
+	}
+
+	var last uint64
+	for i := 0; i < 3*eventSequenceBlock; i++ {
+		eventLogMu.Lock()
+		last = nextEventID()
+		eventLogMu.Unlock()
+	}
+
+	// Die Datei wird im Hintergrund geschrieben
+	deadline := time.Now().Add(2 * time.Second)
+	for readFile() <= last && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := readFile(); n <= last {
+		t.Fatalf("Obergrenze %d, aber bereits Nummer %d vergeben", n, last)
+	}
+
+	eventLogMu.Lock()
+	eventSeq = 0
+	eventLogMu.Unlock()
+	restoreEventSequence()
+	eventLogMu.Lock()
+	next := nextEventID()
+	eventLogMu.Unlock()
+	if next <= last {
+		t.Errorf("nach dem Neustart Nummer %d, zuvor bereits %d vergeben", next, last)
+	}
+}
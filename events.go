@@ -0,0 +1,96 @@
+package inspector
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Die Ereignisse, die an die SSE-Clients gesendet werden, und der JSON-Inhalt ihres data-Felds:
+//
+//	request.created    eine neu aufgezeichnete oder importierte Anfrage (Request)
+//	request.updated    eine Anfrage mit geänderten Tags, Notiz oder Markierung (Request)
+//	request.deleted    eine einzeln gelöschte Anfrage (RequestDeletedEvent)
+//	bin.cleared        alle Anfragen eines Bins wurden gelöscht (BinClearedEvent)
+//	retention.evicted  Anfragen wurden durch die Aufbewahrungsgrenzen entfernt (RetentionEvictedEvent)
+//	server.shutdown    der Server wird beendet, an alle Clients unabhängig vom Bin (ServerShutdownEvent)
+//
+// Die Filter eines Clients gelten für die Ereignisse request.*, die übrigen erhalten alle Clients des Bins.
+const (
+	eventRequestCreated   = "request.created"
+	eventRequestUpdated   = "request.updated"
+	eventRequestDeleted   = "request.deleted"
+	eventBinCleared       = "bin.cleared"
+	eventRetentionEvicted = "retention.evicted"
+	eventServerShutdown   = "server.shutdown"
+)
+
+// Inhalt von request.deleted
+type RequestDeletedEvent struct {
+	ID  string `json:"id"`
+	Bin string `json:"bin"`
+}
+
+// Inhalt von bin.cleared. BinDeleted ist gesetzt, wenn der Bin selbst ebenfalls gelöscht wurde.
+type BinClearedEvent struct {
+	Bin        string `json:"bin"`
+	Deleted    int    `json:"deleted"`
+	BinDeleted bool   `json:"bin_deleted,omitempty"`
+}
+
+// Inhalt von retention.evicted
+type RetentionEvictedEvent struct {
+	Bin string   `json:"bin"`
+	IDs []string `json:"ids"`
+}
+
+// Inhalt von server.shutdown
+type ServerShutdownEvent struct {
+	Reason string    `json:"reason"`
+	Time   time.Time `json:"time"`
+}
+
+// Wie lange beim Beenden höchstens gewartet wird, bis die SSE-Clients das Ereignis server.shutdown erhalten haben
+const sseShutdownGrace = 2 * time.Second
+
+// Sendet retention.evicted je Bin für die entfernten Anfragen
+func broadcastEvictions(evicted []Request) {
+	ids := make(map[string][]string)
+	for _, r := range evicted {
+		ids[r.Bin] = append(ids[r.Bin], r.ID)
+	}
+	for _, bin := range sortedKeys(ids) {
+		broadcastEvent(bin, eventRetentionEvicted, RetentionEvictedEvent{Bin: bin, IDs: ids[bin]})
+	}
+}
+
+// Sendet bei SIGINT oder SIGTERM server.shutdown an alle Clients und beendet den Prozess,
+// sobald die Warteschlangen geleert sind oder sseShutdownGrace verstrichen ist
+func handleShutdownSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.Println("Server wird beendet:", sig)
+		broadcastEvent("", eventServerShutdown, ServerShutdownEvent{Reason: sig.String(), Time: time.Now()})
+
+		deadline := time.Now().Add(sseShutdownGrace)
+		for time.Now().Before(deadline) && pendingSSEMessages() > 0 {
+			time.Sleep(50 * time.Millisecond)
+		}
+		os.Exit(0)
+	}()
+}
+
+// Anzahl der Nachrichten, die noch in den Warteschlangen der SSE-Clients liegen
+func pendingSSEMessages() int {
+	SSEClientsMu.RLock()
+	defer SSEClientsMu.RUnlock()
+	n := 0
+	for _, client := range SSEClients {
+		n += client.queue.pending()
+	}
+	return n
+}
@@ -0,0 +1,184 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Filter für /view-requests, /stats, /sse, /ws und /requests/wait.
+// Alle angegebenen Bedingungen müssen zutreffen. Mehrere Methoden sind Alternativen,
+// mehrere Tags, Header und Felder müssen alle passen. Die Notiz wird ohne Groß-/Kleinschreibung durchsucht.
+type requestFilter struct {
+	Tags    []string
+	Starred *bool
+	Note    string
+	Methods []string
+	Path    string // Pfadmuster wie bei den Schema-Regeln, relativ zum Bin
+	Headers []headerCondition
+	Fields  []fieldCondition
+}
+
+// Ein Header, der vorhanden sein und, falls Value nicht leer ist, auf das Muster passen muss
+type headerCondition struct {
+	Name  string
+	Value string // Muster wie bei path.Match, z. B. "application/*"
+}
+
+// Ein Feld im Body, das den erwarteten JSON-Wert haben muss
+type fieldCondition struct {
+	Field    string // Pfad wie "order.items.0.id"
+	Expected interface{}
+}
+
+// Liest den Filter aus den Query-Parametern der Anfrage
+func parseRequestFilter(c *gin.Context) (requestFilter, error) {
+	return parseFilterValues(c.Request.URL.Query())
+}
+
+// Liest den Filter aus Query-Parametern:
+// tag (mehrfach), starred, note, method (mehrfach), path, header=Name oder header=Name:Muster (mehrfach)
+// und field=Pfad:Wert (mehrfach). Der Wert eines Feldes wird als JSON gelesen, sonst als Zeichenkette.
+func parseFilterValues(query url.Values) (requestFilter, error) {
+	f := requestFilter{
+		Tags: normalizeTags(query["tag"]),
+		Note: strings.ToLower(query.Get("note")),
+		Path: query.Get("path"),
+	}
+	if value := query.Get("starred"); value != "" {
+		starred, err := strconv.ParseBool(value)
+		if err != nil {
+			return f, fmt.Errorf("starred muss true oder false sein")
+		}
+		f.Starred = &starred
+	}
+	for _, method := range query["method"] {
+		if method = strings.TrimSpace(method); method != "" {
+			f.Methods = append(f.Methods, strings.ToUpper(method))
+		}
+	}
+	if f.Path != "" {
+		if _, err := path.Match(f.Path, ""); err != nil {
+			return f, fmt.Errorf("ungültiges Pfadmuster %q", f.Path)
+		}
+	}
+	for _, value := range query["header"] {
+		name, pattern, _ := strings.Cut(value, ":")
+		name, pattern = strings.TrimSpace(name), strings.TrimSpace(pattern)
+		if name == "" {
+			return f, fmt.Errorf("header muss als Name oder Name:Muster angegeben werden")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return f, fmt.Errorf("ungültiges Muster für Header %q", name)
+		}
+		f.Headers = append(f.Headers, headerCondition{Name: name, Value: pattern})
+	}
+	for _, value := range query["field"] {
+		field, raw, ok := strings.Cut(value, ":")
+		if !ok || field == "" {
+			return f, fmt.Errorf("field muss als Pfad:Wert angegeben werden")
+		}
+		var expected interface{}
+		if err := json.Unmarshal([]byte(raw), &expected); err != nil {
+			expected = raw
+		}
+		f.Fields = append(f.Fields, fieldCondition{Field: field, Expected: expected})
+	}
+	return f, nil
+}
+
+// Prüft, ob eine Anfrage auf den Filter passt. Für Feldbedingungen wird der gespeicherte Body gelesen.
+func (f requestFilter) matches(r Request) bool {
+	if !f.matchesMetadata(r) {
+		return false
+	}
+	if len(f.Fields) == 0 {
+		return true
+	}
+	// Der Body wird erst gelesen, wenn alle anderen Bedingungen zutreffen
+	doc, err := requestDocument(r)
+	return err == nil && f.matchesFields(doc)
+}
+
+// Prüft alle Bedingungen außer den Feldern im Body
+func (f requestFilter) matchesMetadata(r Request) bool {
+	if f.Starred != nil && r.Starred != *f.Starred {
+		return false
+	}
+	if f.Note != "" && !strings.Contains(strings.ToLower(r.Note), f.Note) {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !containsString(r.Tags, tag) {
+			return false
+		}
+	}
+	if len(f.Methods) > 0 && !containsString(f.Methods, r.Method) {
+		return false
+	}
+	if f.Path != "" {
+		u, err := url.Parse(r.URL)
+		if err != nil || !matchPath(f.Path, pathInBin(u.Path)) {
+			return false
+		}
+	}
+	for _, h := range f.Headers {
+		if !matchesHeader(r, h) {
+			return false
+		}
+	}
+	return true
+}
+
+// Prüft die Feldbedingungen gegen das Body-Dokument einer Anfrage
+func (f requestFilter) matchesFields(doc interface{}) bool {
+	for _, cond := range f.Fields {
+		actual, ok := lookupField(doc, cond.Field)
+		if !ok || !jsonEqual(actual, cond.Expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// Liest den gespeicherten Body einer Anfrage als Dokument für die Feldbedingungen
+func requestDocument(r Request) (interface{}, error) {
+	body, err := readRequestBody(r)
+	if err != nil {
+		return nil, err
+	}
+	return bodyDocument(r.BodyParams, body), nil
+}
+
+// Prüft, ob einer der Werte des Headers auf das Muster passt
+func matchesHeader(r Request, h headerCondition) bool {
+	values := r.Headers.Values(h.Name)
+	if len(values) == 0 {
+		return false
+	}
+	if h.Value == "" {
+		return true
+	}
+	for _, v := range values {
+		if ok, _ := path.Match(h.Value, v); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Liefert die Anfragen, die auf den Filter passen, in unveränderter Reihenfolge
+func filterRequests(list []Request, f requestFilter) []Request {
+	result := []Request{}
+	for _, r := range list {
+		if f.matches(r) {
+			result = append(result, r)
+		}
+	}
+	return result
+}
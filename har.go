@@ -0,0 +1,352 @@
+package inspector
+
+import (
+	"encoding/base64"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/net/http/httpguts"
+)
+
+// Ein HTTP Archive im Format 1.2, wie es z. B. die Entwicklerwerkzeuge der Browser lesen.
+// Felder mit Unterstrich sind nach der Spezifikation erlaubte eigene Erweiterungen.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	ID              string      `json:"_id,omitempty"`
+	Bin             string      `json:"_bin,omitempty"`
+	RemoteAddr      string      `json:"_remoteAddr,omitempty"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// Der Request-Body. Binäre Bodies werden wie bei HARContent Base64-kodiert und mit "encoding" markiert.
+type HARPostData struct {
+	MimeType string         `json:"mimeType"`
+	Text     string         `json:"text"`
+	Params   []HARNameValue `json:"params,omitempty"`
+	Encoding string         `json:"encoding,omitempty"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Wandelt einen http.Header in eine nach Namen sortierte HAR-Liste um
+func harHeaders(h http.Header) []HARNameValue {
+	list := []HARNameValue{}
+	for name, values := range h {
+		for _, v := range values {
+			list = append(list, HARNameValue{Name: name, Value: v})
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Kodiert einen Body als Text oder, falls er kein gültiges UTF-8 ist, als Base64
+func harText(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// Dekodiert einen Text aus einem HAR-Archiv abhängig von seiner Kodierung
+func harBytes(text, encoding string) ([]byte, error) {
+	if encoding == "base64" {
+		return base64.StdEncoding.DecodeString(text)
+	}
+	return []byte(text), nil
+}
+
+// Wandelt eine gespeicherte Anfrage in einen HAR-Eintrag um. Der Body wird aus "static-files" gelesen.
+func harEntry(r Request) HAREntry {
+	entry := HAREntry{
+		StartedDateTime: r.Timestamp,
+		ID:              r.ID,
+		Bin:             r.Bin,
+		RemoteAddr:      r.RemoteAddr,
+		Request: HARRequest{
+			Method:      r.Method,
+			URL:         config.BaseURL + r.URL,
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []HARNameValue{},
+			Headers:     harHeaders(r.Headers),
+			QueryString: []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Response: HARResponse{
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []HARNameValue{},
+			Headers:     []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+	}
+
+	if u, err := url.Parse(r.URL); err == nil {
+		for name, values := range u.Query() {
+			for _, v := range values {
+				entry.Request.QueryString = append(entry.Request.QueryString, HARNameValue{Name: name, Value: v})
+			}
+		}
+	}
+
+	if len(r.BodyParams) > 0 {
+		// Auch Multipart-Formulare werden URL-kodiert ausgegeben, der Body selbst ist nicht gespeichert.
+		// mimeType beschreibt text, der Content-Type-Header bleibt der ursprüngliche.
+		form := url.Values{}
+		post := &HARPostData{MimeType: "application/x-www-form-urlencoded"}
+		for name, value := range r.BodyParams {
+			form.Set(name, value)
+			post.Params = append(post.Params, HARNameValue{Name: name, Value: value})
+		}
+		sort.Slice(post.Params, func(i, j int) bool {
+			return post.Params[i].Name < post.Params[j].Name
+		})
+		post.Text = form.Encode()
+		entry.Request.PostData = post
+		entry.Request.BodySize = len(post.Text)
+	} else if body, err := readRequestBody(r); err != nil {
+		log.Println("Fehler beim Lesen des Request-Body:", err)
+	} else if body != nil {
+		post := &HARPostData{MimeType: r.ContentType}
+		post.Text, post.Encoding = harText(body)
+		entry.Request.PostData = post
+		entry.Request.BodySize = len(body)
+	}
+
+	// Anfragen ohne aufgezeichnete Antwort erhalten Status 0, wie abgebrochene Anfragen in den Browsern
+	if resp := r.Response; resp != nil {
+		entry.Time = float64(resp.DurationMs)
+		entry.Timings.Wait = float64(resp.DurationMs)
+		entry.Response.Status = resp.Status
+		entry.Response.StatusText = http.StatusText(resp.Status)
+		entry.Response.Headers = harHeaders(resp.Headers)
+		entry.Response.BodySize = len(resp.Body)
+		entry.Response.Content.Size = len(resp.Body)
+		entry.Response.Content.MimeType = resp.Headers.Get("Content-Type")
+		entry.Response.Content.Text, entry.Response.Content.Encoding = harText(resp.Body)
+	}
+	return entry
+}
+
+// Exportiert Anfragen als HTTP Archive
+func exportHAR(c *gin.Context, bin string, list []Request) {
+	har := HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "HTTP-Requests", Version: "1.0"},
+		Entries: make([]HAREntry, 0, len(list)),
+	}}
+	// HAR-Archive sind chronologisch sortiert, die Liste ist neueste zuerst
+	for i := len(list) - 1; i >= 0; i-- {
+		har.Log.Entries = append(har.Log.Entries, harEntry(list[i]))
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="requests-%s.har"`, bin))
+	c.IndentedJSON(http.StatusOK, har)
+}
+
+// Wandelt einen HAR-Eintrag in eine Anfrage des Bins um, als wäre sie aufgezeichnet worden, und liefert
+// den Body dazu. Die ursprüngliche Zeit bleibt erhalten. Es wird noch nichts gespeichert oder geschwärzt.
+func requestFromHAR(bin string, entry HAREntry) (Request, []byte, error) {
+	u, err := url.Parse(entry.Request.URL)
+	if err != nil {
+		return Request{}, nil, fmt.Errorf("ungültige URL %q", entry.Request.URL)
+	}
+	if entry.Request.Method == "" {
+		return Request{}, nil, fmt.Errorf("Methode fehlt")
+	}
+	// Eine Methode ist wie ein Header-Name ein Token im Sinne von RFC 9110
+	if !httpguts.ValidHeaderFieldName(entry.Request.Method) {
+		return Request{}, nil, fmt.Errorf("ungültige Methode %q", entry.Request.Method)
+	}
+	target := pathInBin(u.Path)
+	if bin != defaultBin {
+		target = "/b/" + bin + target
+	}
+	if u.RawQuery != "" {
+		target += "?" + u.RawQuery
+	}
+
+	headers := make(http.Header)
+	for _, h := range entry.Request.Headers {
+		// HTTP/2-Pseudo-Header aus den Browsern gehören nicht zu den Headern der Anfrage
+		if strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		headers.Add(h.Name, h.Value)
+	}
+
+	req := Request{
+		ID:          uuid.New().String(),
+		Bin:         bin,
+		Method:      strings.ToUpper(entry.Request.Method),
+		URL:         target,
+		Timestamp:   entry.StartedDateTime,
+		RemoteAddr:  entry.RemoteAddr,
+		UserAgent:   headers.Get("User-Agent"),
+		ContentType: headers.Get("Content-Type"),
+		Headers:     headers,
+		BodyParams:  make(map[string]string),
+	}
+	if req.Timestamp.IsZero() {
+		req.Timestamp = time.Now()
+	}
+
+	var body []byte
+	if post := entry.Request.PostData; post != nil {
+		if req.ContentType == "" {
+			req.ContentType, _, _ = mime.ParseMediaType(post.MimeType)
+		}
+		if len(post.Params) > 0 {
+			for _, p := range post.Params {
+				req.BodyParams[p.Name] = p.Value
+			}
+			req.BodySize = len(post.Text)
+		} else if post.Text != "" {
+			if body, err = harBytes(post.Text, post.Encoding); err != nil {
+				return Request{}, nil, fmt.Errorf("Body ist nicht Base64-kodiert")
+			}
+		}
+	}
+
+	if resp := entry.Response; resp.Status > 0 {
+		recorded := &RecordedResponse{Status: resp.Status, Headers: make(http.Header), DurationMs: int64(entry.Time)}
+		for _, h := range resp.Headers {
+			recorded.Headers.Add(h.Name, h.Value)
+		}
+		if recorded.Body, err = harBytes(resp.Content.Text, resp.Content.Encoding); err != nil {
+			return Request{}, nil, fmt.Errorf("Antwort ist nicht Base64-kodiert")
+		}
+		req.Response = recorded
+		req.Status = recorded.Status
+	}
+	if body != nil {
+		req.BodySize = len(body)
+	}
+	return req, body, nil
+}
+
+// Importiert die Einträge eines HTTP Archive in einen Bin. Die Anfragen werden wie aufgezeichnete
+// Anfragen gespeichert und an die SSE-Clients gesendet.
+func importHAR(c *gin.Context) {
+	bin, ok := binFromQuery(c)
+	if !ok {
+		return
+	}
+	if !authorizeBin(c, bin, true) {
+		return
+	}
+
+	var har HAR
+	if err := c.ShouldBindJSON(&har); err != nil {
+		c.String(http.StatusBadRequest, "Ungültiges HAR-Archiv")
+		return
+	}
+
+	// Erst alle Einträge prüfen, damit ein fehlerhafter Eintrag keine Dateien der vorherigen zurücklässt
+	imported := make([]Request, 0, len(har.Log.Entries))
+	bodies := make([][]byte, 0, len(har.Log.Entries))
+	for i, entry := range har.Log.Entries {
+		req, body, err := requestFromHAR(bin, entry)
+		if err != nil {
+			c.String(http.StatusBadRequest, fmt.Sprintf("Eintrag %d: %s", i, err))
+			return
+		}
+		imported = append(imported, req)
+		bodies = append(bodies, body)
+	}
+	// Sensible Werte werden wie bei aufgezeichneten Anfragen geschwärzt
+	for i := range imported {
+		imported[i] = storeCapturedRequest(imported[i], bodies[i])
+	}
+
+	// Senden vergibt die Ereignisnummern, die mit den Anfragen gespeichert werden
+	for i := range imported {
+		SendToAllClients(&imported[i])
+	}
+
+	// Die importierten Anfragen werden nach ihrer ursprünglichen Zeit einsortiert
+	requestsMu.Lock()
+	requests = append(requests, imported...)
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[j].Timestamp.Before(requests[i].Timestamp)
+	})
+	requestsMu.Unlock()
+
+	for _, req := range imported {
+		saveToFile(req)
+	}
+	enforceRetention()
+
+	c.JSON(http.StatusCreated, gin.H{"imported": len(imported)})
+}
@@ -0,0 +1,83 @@
+package inspector
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+)
+
+func TestImportHAR(t *testing.T) {
+	entry := func(method, text string) HAREntry {
+		e := HAREntry{Request: HARRequest{Method: method, URL: "http://example.com/hook"}}
+		if text != "" {
+			e.Request.PostData = &HARPostData{MimeType: "application/json", Text: text}
+		}
+		return e
+	}
+
+	tests := []struct {
+		name       string
+		entries    []HAREntry
+		wantStatus int
+		wantFiles  int // gespeicherte Bodies
+	}{
+		{"gültig", []HAREntry{entry("POST", `{"a":1}`), entry("get", "")}, http.StatusCreated, 1},
+		{"zweiter Eintrag ohne Methode", []HAREntry{entry("POST", `{"a":1}`), entry("", "")}, http.StatusBadRequest, 0},
+		{"Methode mit Leerzeichen", []HAREntry{entry("GET; rm -rf ~", "")}, http.StatusBadRequest, 0},
+		{"zweiter Eintrag nicht Base64", []HAREntry{entry("POST", `{"a":1}`), {Request: HARRequest{Method: "POST", URL: "/x",
+			PostData: &HARPostData{Text: "%%", Encoding: "base64"}}}}, http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(t)
+			har := HAR{Log: HARLog{Version: "1.2", Entries: tt.entries}}
+			if status := managementRequest(t, s, http.MethodPost, "/requests/import", s.AdminToken, har); status != tt.wantStatus {
+				t.Fatalf("Status %d, erwartet %d", status, tt.wantStatus)
+			}
+			files, err := os.ReadDir(dataPath(config.StaticFilesDir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != tt.wantFiles {
+				t.Errorf("%d Body-Dateien, erwartet %d", len(files), tt.wantFiles)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				if list := s.Requests(defaultBin); len(list) != 0 {
+					t.Errorf("%d Anfragen importiert, erwartet keine", len(list))
+				}
+			}
+		})
+	}
+}
+
+// Formularfelder werden URL-kodiert exportiert, mimeType muss dazu passen
+func TestHAREntryFormParams(t *testing.T) {
+	s := NewServer(t)
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("name", "a b")
+	w.WriteField("menge", "2")
+	w.Close()
+	resp, err := http.Post(s.URL(defaultBin, "/form"), w.FormDataContentType(), &body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	list := s.Requests(defaultBin)
+	if len(list) != 1 || len(list[0].BodyParams) != 2 {
+		t.Fatalf("Formularfelder nicht aufgezeichnet: %+v", list)
+	}
+	post := harEntry(list[0]).Request.PostData
+	if post == nil || post.MimeType != "application/x-www-form-urlencoded" {
+		t.Fatalf("postData %+v, erwartet mimeType application/x-www-form-urlencoded", post)
+	}
+	form, err := url.ParseQuery(post.Text)
+	if err != nil || form.Get("name") != "a b" || form.Get("menge") != "2" || len(post.Params) != 2 {
+		t.Errorf("postData %+v passt nicht zu den Formularfeldern", post)
+	}
+}
@@ -0,0 +1,517 @@
+package inspector
+
+import (
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Eine Verletzung eines JSON Schemas
+type Violation struct {
+	InstancePath string `json:"instance_path"`
+	Keyword      string `json:"keyword"`
+	Message      string `json:"message"`
+}
+
+// Höchstzahl der geprüften (Schema, Wert)-Paare je Dokument. Verschachtelte anyOf/oneOf über gemeinsame
+// Referenzen vervielfachen die Arbeit mit jeder Ebene, auch ohne Zyklus.
+const maxSchemaSteps = 100000
+
+// Validiert JSON-Dokumente gegen ein Schema.
+// root ist das Dokument, in dem lokale Referenzen ("#/components/schemas/...") aufgelöst werden.
+// steps teilen sich alle Validatoren eines Dokuments, auch die für anyOf, oneOf und not.
+type schemaValidator struct {
+	root       interface{}
+	steps      *int
+	violations []Violation
+}
+
+// Validiert instance gegen schema. Referenzen werden relativ zu root aufgelöst.
+// Unterstützt wird die gängige Teilmenge von JSON Schema (Draft 7 bis 2020-12) inklusive "nullable" aus OpenAPI 3.0.
+func validateSchema(root, schema, instance interface{}) []Violation {
+	steps := maxSchemaSteps
+	v := &schemaValidator{root: root, steps: &steps}
+	v.validate(schema, instance, "", 0)
+	if steps < 0 {
+		// Ergebnisse der abgebrochenen Zweige sind unvollständig
+		return []Violation{{InstancePath: "", Keyword: "$ref", Message: fmt.Sprintf("Prüfung nach %d Schritten abgebrochen, Schema ist zu aufwendig", maxSchemaSteps)}}
+	}
+	return v.violations
+}
+
+// Ein Validator für einen Teilzweig, der dasselbe Budget verbraucht
+func (v *schemaValidator) inner() *schemaValidator {
+	return &schemaValidator{root: v.root, steps: v.steps}
+}
+
+func (v *schemaValidator) fail(path, keyword, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{InstancePath: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+}
+
+// Löst eine lokale Referenz der Form "#/a/b/c" im Wurzeldokument auf
+func (v *schemaValidator) resolveRef(ref string) (interface{}, bool) {
+	return resolvePointer(v.root, ref)
+}
+
+// Folgt einem JSON Pointer ("#/a/b/c") durch ein Dokument
+func resolvePointer(doc interface{}, ref string) (interface{}, bool) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, false
+	}
+	node := doc
+	for _, part := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if part == "" {
+			continue
+		}
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[part]
+			if !ok {
+				return nil, false
+			}
+			node = child
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, false
+			}
+			node = n[i]
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+func (v *schemaValidator) validate(schema, instance interface{}, path string, depth int) {
+	*v.steps--
+	if *v.steps < 0 {
+		return
+	}
+	// Schutz vor zyklischen Referenzen
+	if depth > 64 {
+		v.fail(path, "$ref", "Schema ist zu tief verschachtelt")
+		return
+	}
+
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			v.fail(path, "false", "kein Wert erlaubt")
+		}
+		return
+	case map[string]interface{}:
+		v.validateObjectSchema(s, instance, path, depth)
+	}
+}
+
+func (v *schemaValidator) validateObjectSchema(s map[string]interface{}, instance interface{}, path string, depth int) {
+	if ref, ok := s["$ref"].(string); ok {
+		target, found := v.resolveRef(ref)
+		if !found {
+			v.fail(path, "$ref", "Referenz %s nicht gefunden", ref)
+			return
+		}
+		v.validate(target, instance, path, depth+1)
+	}
+
+	// OpenAPI 3.0: "nullable: true" erlaubt zusätzlich null
+	if instance == nil {
+		if nullable, _ := s["nullable"].(bool); nullable {
+			return
+		}
+	}
+
+	if t, ok := s["type"]; ok {
+		var types []string
+		switch tt := t.(type) {
+		case string:
+			types = []string{tt}
+		case []interface{}:
+			for _, x := range tt {
+				if str, ok := x.(string); ok {
+					types = append(types, str)
+				}
+			}
+		}
+		matched := false
+		for _, typ := range types {
+			if hasJSONType(instance, typ) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(path, "type", "erwartet %s, erhalten %s", strings.Join(types, " oder "), jsonTypeOf(instance))
+			return
+		}
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			if jsonEqual(e, instance) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			v.fail(path, "enum", "Wert ist keiner der erlaubten Werte")
+		}
+	}
+	if c, ok := s["const"]; ok && !jsonEqual(c, instance) {
+		v.fail(path, "const", "Wert entspricht nicht der Konstante")
+	}
+
+	for _, sub := range schemaList(s["allOf"]) {
+		v.validate(sub, instance, path, depth+1)
+	}
+	if anyOf := schemaList(s["anyOf"]); len(anyOf) > 0 {
+		if v.countMatches(anyOf, instance, path, depth, 1) == 0 {
+			v.fail(path, "anyOf", "Wert passt auf keines der Schemas")
+		}
+	}
+	if oneOf := schemaList(s["oneOf"]); len(oneOf) > 0 {
+		if n := v.countMatches(oneOf, instance, path, depth, 2); n != 1 {
+			v.fail(path, "oneOf", "Wert passt auf %s statt genau eines der Schemas", map[int]string{0: "keines", 2: "mehrere"}[n])
+		}
+	}
+	if not, ok := s["not"]; ok {
+		inner := v.inner()
+		inner.validate(not, instance, path, depth+1)
+		if len(inner.violations) == 0 {
+			v.fail(path, "not", "Wert darf nicht auf das Schema passen")
+		}
+	}
+
+	switch x := instance.(type) {
+	case string:
+		v.validateString(s, x, path)
+	case float64:
+		v.validateNumber(s, x, path)
+	case []interface{}:
+		v.validateArray(s, x, path, depth)
+	case map[string]interface{}:
+		v.validateObject(s, x, path, depth)
+	}
+}
+
+// Zählt, auf wie viele der Schemas der Wert passt, höchstens bis limit
+func (v *schemaValidator) countMatches(schemas []interface{}, instance interface{}, path string, depth, limit int) int {
+	n := 0
+	for _, sub := range schemas {
+		if n == limit {
+			break
+		}
+		inner := v.inner()
+		inner.validate(sub, instance, path, depth+1)
+		if len(inner.violations) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func (v *schemaValidator) validateString(s map[string]interface{}, x string, path string) {
+	length := len([]rune(x))
+	if min, ok := number(s["minLength"]); ok && float64(length) < min {
+		v.fail(path, "minLength", "mindestens %v Zeichen erwartet", min)
+	}
+	if max, ok := number(s["maxLength"]); ok && float64(length) > max {
+		v.fail(path, "maxLength", "höchstens %v Zeichen erlaubt", max)
+	}
+	if pattern, ok := s["pattern"].(string); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			v.fail(path, "pattern", "ungültiger Ausdruck %q", pattern)
+		} else if !re.MatchString(x) {
+			v.fail(path, "pattern", "Wert passt nicht auf %q", pattern)
+		}
+	}
+	if format, ok := s["format"].(string); ok && !matchesFormat(format, x) {
+		v.fail(path, "format", "Wert ist kein gültiges %s", format)
+	}
+}
+
+func (v *schemaValidator) validateNumber(s map[string]interface{}, x float64, path string) {
+	if min, ok := number(s["minimum"]); ok && x < min {
+		v.fail(path, "minimum", "Wert muss >= %v sein", min)
+	}
+	if max, ok := number(s["maximum"]); ok && x > max {
+		v.fail(path, "maximum", "Wert muss <= %v sein", max)
+	}
+	// exclusiveMinimum/-Maximum sind ab Draft 6 Zahlen, in OpenAPI 3.0 boolesche Modifikatoren
+	if min, ok := number(s["exclusiveMinimum"]); ok && x <= min {
+		v.fail(path, "exclusiveMinimum", "Wert muss > %v sein", min)
+	} else if excl, _ := s["exclusiveMinimum"].(bool); excl {
+		if min, ok := number(s["minimum"]); ok && x == min {
+			v.fail(path, "exclusiveMinimum", "Wert muss > %v sein", min)
+		}
+	}
+	if max, ok := number(s["exclusiveMaximum"]); ok && x >= max {
+		v.fail(path, "exclusiveMaximum", "Wert muss < %v sein", max)
+	} else if excl, _ := s["exclusiveMaximum"].(bool); excl {
+		if max, ok := number(s["maximum"]); ok && x == max {
+			v.fail(path, "exclusiveMaximum", "Wert muss < %v sein", max)
+		}
+	}
+	if m, ok := number(s["multipleOf"]); ok && m > 0 {
+		q := x / m
+		if math.Abs(q-math.Round(q)) > 1e-9 {
+			v.fail(path, "multipleOf", "Wert muss ein Vielfaches von %v sein", m)
+		}
+	}
+}
+
+func (v *schemaValidator) validateArray(s map[string]interface{}, x []interface{}, path string, depth int) {
+	if min, ok := number(s["minItems"]); ok && float64(len(x)) < min {
+		v.fail(path, "minItems", "mindestens %v Elemente erwartet", min)
+	}
+	if max, ok := number(s["maxItems"]); ok && float64(len(x)) > max {
+		v.fail(path, "maxItems", "höchstens %v Elemente erlaubt", max)
+	}
+	if unique, _ := s["uniqueItems"].(bool); unique {
+		for i := range x {
+			for j := i + 1; j < len(x); j++ {
+				if jsonEqual(x[i], x[j]) {
+					v.fail(path, "uniqueItems", "Elemente %d und %d sind gleich", i, j)
+				}
+			}
+		}
+	}
+
+	// "prefixItems" (2020-12) bzw. "items" als Liste (Draft 7) prüfen die ersten Elemente einzeln
+	prefix := schemaList(s["prefixItems"])
+	if list, ok := s["items"].([]interface{}); ok {
+		prefix = list
+	}
+	for i, sub := range prefix {
+		if i < len(x) {
+			v.validate(sub, x[i], fmt.Sprintf("%s/%d", path, i), depth+1)
+		}
+	}
+	if items, ok := s["items"]; ok {
+		if _, isList := items.([]interface{}); !isList {
+			for i := len(prefix); i < len(x); i++ {
+				v.validate(items, x[i], fmt.Sprintf("%s/%d", path, i), depth+1)
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) validateObject(s map[string]interface{}, x map[string]interface{}, path string, depth int) {
+	if min, ok := number(s["minProperties"]); ok && float64(len(x)) < min {
+		v.fail(path, "minProperties", "mindestens %v Eigenschaften erwartet", min)
+	}
+	if max, ok := number(s["maxProperties"]); ok && float64(len(x)) > max {
+		v.fail(path, "maxProperties", "höchstens %v Eigenschaften erlaubt", max)
+	}
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, present := x[name]; !present {
+					v.fail(path, "required", "Eigenschaft %q fehlt", name)
+				}
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]interface{})
+	patternProperties, _ := s["patternProperties"].(map[string]interface{})
+
+	// Sortierte Schlüssel für eine stabile Reihenfolge der Fehlermeldungen
+	keys := make([]string, 0, len(x))
+	for k := range x {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		childPath := path + "/" + escapePointer(key)
+		matched := false
+		if sub, ok := properties[key]; ok {
+			v.validate(sub, x[key], childPath, depth+1)
+			matched = true
+		}
+		for pattern, sub := range patternProperties {
+			if re, err := regexp.Compile(pattern); err == nil && re.MatchString(key) {
+				v.validate(sub, x[key], childPath, depth+1)
+				matched = true
+			}
+		}
+		if matched {
+			continue
+		}
+		if additional, ok := s["additionalProperties"]; ok {
+			if allowed, isBool := additional.(bool); isBool && !allowed {
+				v.fail(childPath, "additionalProperties", "Eigenschaft %q ist nicht erlaubt", key)
+			} else if !isBool {
+				v.validate(additional, x[key], childPath, depth+1)
+			}
+		}
+	}
+}
+
+// Schlüssel, deren Werte Daten und keine Schemas sind
+var schemaDataKeywords = map[string]bool{"enum": true, "const": true, "default": true, "example": true, "examples": true}
+
+// Sucht Zyklen aus "$ref", "allOf", "anyOf", "oneOf" und "not", die ohne Abstieg in die Instanz
+// wieder beim selben Schema ankommen, z. B. {"not": {"$ref": "#"}}. Solche Schemas laufen bei jeder
+// Prüfung bis zur Tiefengrenze. Rekursion über "properties" oder "items" ist erlaubt, sie endet mit der Instanz.
+// Geprüft werden alle Objekte in doc, also auch sämtliche Schemas eines OpenAPI-Dokuments.
+func checkSchemaRecursion(doc interface{}) error {
+	c := &recursionCheck{root: doc, state: make(map[uintptr]int)}
+	return c.walk(doc)
+}
+
+type recursionCheck struct {
+	root  interface{}
+	state map[uintptr]int // 1: wird gerade besucht, 2: ohne Zyklus abgeschlossen
+}
+
+func (c *recursionCheck) walk(node interface{}) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if err := c.visit(n, ""); err != nil {
+			return err
+		}
+		for key, child := range n {
+			if schemaDataKeywords[key] {
+				continue
+			}
+			if err := c.walk(child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range n {
+			if err := c.walk(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Folgt den Schlüsseln, die dieselbe Instanz erneut prüfen. ref ist die Referenz, über die s erreicht wurde.
+func (c *recursionCheck) visit(s map[string]interface{}, ref string) error {
+	p := reflect.ValueOf(s).Pointer()
+	switch c.state[p] {
+	case 1:
+		return fmt.Errorf("Schema verweist über %s endlos auf sich selbst", ref)
+	case 2:
+		return nil
+	}
+	c.state[p] = 1
+
+	if r, ok := s["$ref"].(string); ok {
+		if target, found := resolvePointer(c.root, r); found {
+			if m, ok := target.(map[string]interface{}); ok {
+				if err := c.visit(m, r); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	var subs []interface{}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		subs = append(subs, schemaList(s[key])...)
+	}
+	for _, sub := range append(subs, s["not"]) {
+		if m, ok := sub.(map[string]interface{}); ok {
+			if err := c.visit(m, ref); err != nil {
+				return err
+			}
+		}
+	}
+
+	c.state[p] = 2
+	return nil
+}
+
+// Maskiert einen Schlüssel für die Verwendung in einem JSON Pointer
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+func schemaList(x interface{}) []interface{} {
+	list, _ := x.([]interface{})
+	return list
+}
+
+func number(x interface{}) (float64, bool) {
+	f, ok := x.(float64)
+	return f, ok
+}
+
+// Prüft, ob ein JSON-Wert dem angegebenen JSON-Schema-Typ entspricht
+func hasJSONType(instance interface{}, typ string) bool {
+	switch typ {
+	case "null":
+		return instance == nil
+	case "boolean":
+		_, ok := instance.(bool)
+		return ok
+	case "string":
+		_, ok := instance.(string)
+		return ok
+	case "number":
+		_, ok := instance.(float64)
+		return ok
+	case "integer":
+		f, ok := instance.(float64)
+		return ok && f == math.Trunc(f)
+	case "array":
+		_, ok := instance.([]interface{})
+		return ok
+	case "object":
+		_, ok := instance.(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+func jsonTypeOf(instance interface{}) string {
+	for _, typ := range []string{"null", "boolean", "string", "integer", "number", "array", "object"} {
+		if hasJSONType(instance, typ) {
+			return typ
+		}
+	}
+	return "unbekannt"
+}
+
+func jsonEqual(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// Prüft die gängigen Formate aus JSON Schema. Unbekannte Formate gelten als gültig.
+func matchesFormat(format, x string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, x)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", x)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(x)
+		return err == nil && addr.Address == x
+	case "uri", "url":
+		u, err := url.Parse(x)
+		return err == nil && u.Scheme != ""
+	case "uuid":
+		_, err := uuid.Parse(x)
+		return err == nil && len(x) == 36
+	}
+	return true
+}
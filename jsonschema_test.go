@@ -0,0 +1,145 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustJSON(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("ungültiges JSON %s: %v", s, err)
+	}
+	return v
+}
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		instance string
+		keywords []string // erwartete Schlüsselwörter der Verletzungen in dieser Reihenfolge
+	}{
+		{"type passt", `{"type":"string"}`, `"a"`, nil},
+		{"type falsch", `{"type":"string"}`, `1`, []string{"type"}},
+		{"type als Liste", `{"type":["string","null"]}`, `null`, nil},
+		{"integer", `{"type":"integer"}`, `1.5`, []string{"type"}},
+		{"nullable", `{"type":"string","nullable":true}`, `null`, nil},
+		{"enum", `{"enum":["a","b"]}`, `"c"`, []string{"enum"}},
+		{"const", `{"const":{"a":1}}`, `{"a":1}`, nil},
+		{"minLength zählt Runen", `{"minLength":2}`, `"ä"`, []string{"minLength"}},
+		{"maxLength", `{"maxLength":1}`, `"ab"`, []string{"maxLength"}},
+		{"pattern", `{"pattern":"^a+$"}`, `"ab"`, []string{"pattern"}},
+		{"format email", `{"format":"email"}`, `"kein mail"`, []string{"format"}},
+		{"format uuid", `{"format":"uuid"}`, `"00000000-0000-4000-8000-000000000000"`, nil},
+		{"unbekanntes format", `{"format":"foo"}`, `"x"`, nil},
+		{"minimum", `{"minimum":1}`, `0`, []string{"minimum"}},
+		{"exclusiveMinimum als Zahl", `{"exclusiveMinimum":1}`, `1`, []string{"exclusiveMinimum"}},
+		{"exclusiveMinimum in OpenAPI 3.0", `{"minimum":1,"exclusiveMinimum":true}`, `1`, []string{"exclusiveMinimum"}},
+		{"multipleOf", `{"multipleOf":0.1}`, `0.3`, nil},
+		{"required", `{"required":["a","b"]}`, `{"a":1}`, []string{"required"}},
+		{"properties", `{"properties":{"a":{"type":"string"}}}`, `{"a":1}`, []string{"type"}},
+		{"additionalProperties false", `{"properties":{"a":{}},"additionalProperties":false}`, `{"a":1,"b":2}`, []string{"additionalProperties"}},
+		{"patternProperties", `{"patternProperties":{"^x-":{"type":"string"}},"additionalProperties":false}`, `{"x-a":"1"}`, nil},
+		{"items", `{"items":{"type":"number"}}`, `[1,"2",3]`, []string{"type"}},
+		{"prefixItems", `{"prefixItems":[{"type":"string"}],"items":{"type":"number"}}`, `["a",1]`, nil},
+		{"uniqueItems", `{"uniqueItems":true}`, `[1,2,1]`, []string{"uniqueItems"}},
+		{"minItems", `{"minItems":2}`, `[1]`, []string{"minItems"}},
+		{"allOf", `{"allOf":[{"type":"number"},{"minimum":5}]}`, `3`, []string{"minimum"}},
+		{"anyOf", `{"anyOf":[{"type":"string"},{"type":"number"}]}`, `true`, []string{"anyOf"}},
+		{"oneOf mehrdeutig", `{"oneOf":[{"type":"number"},{"minimum":0}]}`, `1`, []string{"oneOf"}},
+		{"not", `{"not":{"type":"string"}}`, `"a"`, []string{"not"}},
+		{"not passt nicht", `{"not":{"type":"string"}}`, `1`, nil},
+		{"false", `false`, `1`, []string{"false"}},
+		{"$ref", `{"$defs":{"n":{"type":"number"}},"$ref":"#/$defs/n"}`, `"a"`, []string{"type"}},
+		{"$ref fehlt", `{"$ref":"#/$defs/x"}`, `1`, []string{"$ref"}},
+		{"rekursiv über properties", `{"properties":{"child":{"$ref":"#"}},"required":["v"]}`, `{"v":1,"child":{"v":2,"child":{}}}`, []string{"required"}},
+		{"zyklisch über $ref", `{"$ref":"#"}`, `1`, []string{"$ref"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := mustJSON(t, tt.schema)
+			violations := validateSchema(schema, schema, mustJSON(t, tt.instance))
+			var keywords []string
+			for _, v := range violations {
+				keywords = append(keywords, v.Keyword)
+			}
+			if strings.Join(keywords, ",") != strings.Join(tt.keywords, ",") {
+				t.Errorf("Verletzungen %v, erwartet %v", violations, tt.keywords)
+			}
+		})
+	}
+}
+
+func TestValidateSchemaInstancePath(t *testing.T) {
+	schema := mustJSON(t, `{"properties":{"a/b":{"items":{"type":"string"}}}}`)
+	violations := validateSchema(schema, schema, mustJSON(t, `{"a/b":["x",1]}`))
+	if len(violations) != 1 || violations[0].InstancePath != "/a~1b/1" {
+		t.Fatalf("Verletzungen %v, erwartet eine unter /a~1b/1", violations)
+	}
+}
+
+// Zyklen über "not" müssen an der Tiefengrenze enden statt den Stack zu sprengen
+func TestValidateSchemaCyclicNot(t *testing.T) {
+	schema := mustJSON(t, `{"not":{"$ref":"#"}}`)
+	validateSchema(schema, schema, 1.0)
+}
+
+// Jede Ebene verweist zweimal auf die nächste: ohne Zyklus und innerhalb der Tiefengrenze, aber mit 2^25 Pfaden
+func TestValidateSchemaStepLimit(t *testing.T) {
+	var defs []string
+	for i := 0; i < 25; i++ {
+		defs = append(defs, fmt.Sprintf(`"l%d":{"anyOf":[{"$ref":"#/$defs/l%d"},{"$ref":"#/$defs/l%d"}]}`, i, i+1, i+1))
+	}
+	defs = append(defs, `"l25":{"type":"string"}`)
+	schema := mustJSON(t, `{"$defs":{`+strings.Join(defs, ",")+`},"$ref":"#/$defs/l0"}`)
+	if err := checkSchemaRecursion(schema); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	violations := validateSchema(schema, schema, 1.0)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Prüfung dauerte %v", elapsed)
+	}
+	if len(violations) != 1 || !strings.Contains(violations[0].Message, "abgebrochen") {
+		t.Errorf("Verletzungen %+v, erwartet den Abbruch", violations)
+	}
+	// Ein passender Wert braucht nur den ersten Zweig jeder Ebene
+	if violations := validateSchema(schema, schema, "text"); len(violations) != 0 {
+		t.Errorf("Verletzungen %+v für einen passenden Wert", violations)
+	}
+}
+
+func TestCheckSchemaRecursion(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		wantErr bool
+	}{
+		{"ohne Referenzen", `{"type":"object","properties":{"a":{"type":"string"}}}`, false},
+		{"Referenz ohne Zyklus", `{"$defs":{"a":{"$ref":"#/$defs/b"},"b":{"type":"string"}},"allOf":[{"$ref":"#/$defs/a"},{"$ref":"#/$defs/a"}]}`, false},
+		{"Rekursion über properties", `{"properties":{"child":{"$ref":"#"}}}`, false},
+		{"Rekursion über items", `{"$defs":{"tree":{"items":{"$ref":"#/$defs/tree"}}},"$ref":"#/$defs/tree"}`, false},
+		{"fehlende Referenz", `{"$ref":"#/nicht/da"}`, false},
+		{"$ref auf sich selbst", `{"$ref":"#"}`, true},
+		{"not auf die Wurzel", `{"not":{"$ref":"#"}}`, true},
+		{"Zyklus über zwei Definitionen", `{"$defs":{"a":{"anyOf":[{"$ref":"#/$defs/b"}]},"b":{"oneOf":[{"type":"null"},{"$ref":"#/$defs/a"}]}}}`, true},
+		{"Zyklus in einem OpenAPI-Dokument", `{"openapi":"3.0.3","paths":{},"components":{"schemas":{"A":{"allOf":[{"$ref":"#/components/schemas/A"}]}}}}`, true},
+		{"Referenzen in Beispielen zählen nicht", `{"example":{"$ref":"#"}}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSchemaRecursion(mustJSON(t, tt.doc))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Fehler %v, erwartet Fehler: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
-package main
+package inspector
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -11,10 +14,12 @@ import (
 	"mime"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -22,22 +27,38 @@ import (
 // Zusätzlich wird die Umwandlung in das json-Format definiert
 type Request struct {
 	ID          string            `json:"id"`
+	Bin         string            `json:"bin"`
 	Method      string            `json:"method"`
 	URL         string            `json:"url"`
 	Timestamp   time.Time         `json:"timestamp"`
 	RemoteAddr  string            `json:"remote_addr"`
 	UserAgent   string            `json:"user_agent"`
 	ContentType string            `json:"content_type"`
+	Headers     http.Header       `json:"headers"`
 	BodyParams  map[string]string `json:"body_params"`
 	LinkToFile  string            `json:"link_to_file"`
+	Signature   *SignatureVerdict `json:"signature,omitempty"`
+	Validation  *ValidationResult `json:"validation,omitempty"`
+	Spec        *SpecResult       `json:"spec,omitempty"`
+	MockRuleID  string            `json:"mock_rule_id,omitempty"`
+	Response    *RecordedResponse `json:"response,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Note        string            `json:"note,omitempty"`
+	Starred     bool              `json:"starred,omitempty"`   // markierte Anfragen sind von der Aufbewahrungsgrenze ausgenommen
+	Status      int               `json:"status,omitempty"`    // Statuscode der Antwort an den Absender
+	BodySize    int               `json:"body_size,omitempty"` // Größe des unveränderten Bodies in Bytes
+	Seq         uint64            `json:"seq,omitempty"`       // Nummer des SSE-Ereignisses, mit dem die Anfrage gesendet wurde
 }
 
 // Slice von Requests anlegen
 var requests []Request
 
-// Lese alle Requests aus der Datei /requests und Speichere sie nach Erstelldatum sortiert in die Slice requests
+// Schützt die Slice requests vor gleichzeitigem Zugriff aus mehreren Handlern
+var requestsMu sync.RWMutex
+
+// Lese alle Requests aus dem Verzeichnis requests_dir und Speichere sie nach Erstelldatum sortiert in die Slice requests
 func restoreRequests() {
-	entries, err := os.ReadDir("./requests")
+	entries, err := os.ReadDir(dataPath(config.RequestsDir))
 	if err != nil {
 		log.Fatal("Fehler beim Lesen der Dateien:", err)
 	}
@@ -45,7 +66,10 @@ func restoreRequests() {
 	var reqs []Request
 
 	for _, entry := range entries {
-		data, err := os.ReadFile(fmt.Sprintf("./requests/%s", entry.Name()))
+		if strings.HasSuffix(entry.Name(), reencryptTempSuffix) {
+			continue
+		}
+		data, err := readStoredFile(dataPath(config.RequestsDir, entry.Name()))
 		if err != nil {
 			log.Println("Fehler beim Lesen der Datei:", err)
 			continue
@@ -56,6 +80,10 @@ func restoreRequests() {
 			log.Println("Fehler beim Entmarshalling des Requests:", err)
 			continue
 		}
+		// Ältere Anfragen ohne Bin gehören zum Standard-Bin
+		if req.Bin == "" {
+			req.Bin = defaultBin
+		}
 		reqs = append(reqs, req)
 	}
 
@@ -64,18 +92,51 @@ func restoreRequests() {
 		return reqs[j].Timestamp.Before(reqs[i].Timestamp)
 	})
 
+	requestsMu.Lock()
 	requests = reqs
+	requestsMu.Unlock()
 }
 
-// Erhält einen gin.Context und wandelt diesen direkt in eine Request-Struct um
-func parseRequest(c *gin.Context) Request {
+// Erhält einen gin.Context und wandelt diesen direkt in eine Request-Struct um.
+// Zusätzlich werden der unveränderte Body, z. B. für die Weiterleitung an einen Upstream,
+// und der Body-Inhalt zurückgegeben, der kein Formular ist und mit storeCapturedRequest gespeichert wird.
+func parseRequest(c *gin.Context) (Request, []byte, []byte) {
 	// Initialisiere eine leere Map, um die Body-Parameter zu speichern
 	bodyParams := make(map[string]string)
 
+	// Erstelle eine Request-Struktur mit den allgemeinen Informationen der Anfrage
+	req := Request{
+		ID:          uuid.New().String(),
+		Bin:         binFromPath(c.Request.URL.Path),
+		Method:      c.Request.Method,
+		URL:         c.Request.URL.String(),
+		Timestamp:   time.Now(),
+		RemoteAddr:  c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		ContentType: c.ContentType(),
+		Headers:     c.Request.Header.Clone(),
+		BodyParams:  bodyParams,
+	}
+
+	// Lies den unveränderten Body für die Signaturprüfung und stelle ihn für das Parsen der Formulare wieder her
+	rawBody, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		log.Println("Fehler beim Lesen des Request-Body:", err)
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(rawBody))
+	req.BodySize = len(rawBody)
+
+	// Prüfe die Webhook-Signatur, falls für den Bin konfiguriert
+	req.Signature = verifySignature(req.Bin, c.Request.Header, rawBody, req.Timestamp)
+
+	// Body-Inhalt, der nicht als Formular gelesen werden kann
+	var bodyContent []byte
+	var contentType string
+
 	// Überprüfe, ob die Anfrage eine POST- oder PUT-Anfrage ist
 	if c.Request.Method == http.MethodPost || c.Request.Method == http.MethodPut {
 		// Ermittle den Content-Type Header der Anfrage
-		contentType := c.GetHeader("Content-Type")
+		contentType = c.GetHeader("Content-Type")
 
 		// Überprüfe, ob es sich um einen "multipart/form-data" Content-Type handelt
 		if strings.HasPrefix(contentType, "multipart/form-data") {
@@ -100,56 +161,60 @@ func parseRequest(c *gin.Context) Request {
 			}
 		}
 		// Wenn keine Parameter bestimmt werden können und der Body eine Länge > 0 hat
-		if len(bodyParams) == 0 && c.Request.ContentLength > 0 {
-			fileContent := c.Request.Body
-			// Lies den Body-Inhalt aus
-			bodyContent, err := io.ReadAll(fileContent)
-			if err != nil {
-				log.Println("Fehler beim Lesen des Request-Body:", err)
-			} else {
-				// Generiere einen Dateinamen
-				// Erkenne die Dateiendung aus dem Content-Type
-				extension, err2 := mime.ExtensionsByType(contentType)
-				if err2 != nil {
-					fmt.Println(err2)
-				}
+		if len(bodyParams) == 0 && len(rawBody) > 0 {
+			bodyContent = rawBody
+		}
+	}
 
-				filename := fmt.Sprintf("%s%s", generateRandomString(6), extension[0])
+	// Prüfe den Body gegen eine registrierte Schema-Regel, bevor Werte geschwärzt werden
+	req.Validation = validateRequest(req, rawBody)
 
-				// Speichere den Body-Inhalt in der "static-files"-Datei
-				err := os.WriteFile(filepath.Join("static-files", filename), bodyContent, 0644)
-				if err != nil {
-					log.Println("Fehler beim Speichern des Body-Inhalts:", err)
-				}
+	// Gleiche die Anfrage im Mock-Modus mit dem OpenAPI-Dokument des Bins ab
+	req.Spec = matchSpec(req, c.Request.Header, rawBody)
 
-				// Erstelle und gib eine Request-Struktur mit den extrahierten Informationen zurück,
-				// inklusive dem Dateilink
-				return Request{
-					ID:          uuid.New().String(),
-					Method:      c.Request.Method,
-					URL:         c.Request.URL.String(),
-					Timestamp:   time.Now(),
-					RemoteAddr:  c.ClientIP(),
-					UserAgent:   c.Request.UserAgent(),
-					ContentType: c.ContentType(),
-					BodyParams:  bodyParams,
-					LinkToFile:  fmt.Sprintf("http://localhost:8080/static/%s", filename), // Setze den Dateilink
-				}
-			}
-		}
+	// Suche eine Mock-Regel, die die Anfrage beantwortet
+	if rule := findMockRule(req, rawBody); rule != nil {
+		req.MockRuleID = rule.ID
 	}
 
-	// Erstelle und gib eine Request-Struktur mit den extrahierten Informationen zurück
-	return Request{
-		ID:          uuid.New().String(),
-		Method:      c.Request.Method,
-		URL:         c.Request.URL.String(),
-		Timestamp:   time.Now(),
-		RemoteAddr:  c.ClientIP(),
-		UserAgent:   c.Request.UserAgent(),
-		ContentType: c.ContentType(),
-		BodyParams:  bodyParams,
+	return req, rawBody, bodyContent
+}
+
+// Schwärzt sensible Werte einer Anfrage samt aufgezeichneter Antwort und speichert den Body-Inhalt.
+// Das Original wird auf Wunsch verschlüsselt aufbewahrt.
+func storeCapturedRequest(req Request, bodyContent []byte) Request {
+	redacted, redactedBody, changed := redactRequest(req, bodyContent)
+	if changed {
+		saveUnredactedCopy(req, bodyContent)
+	}
+	req = redacted
+
+	if bodyContent != nil {
+		req.LinkToFile = saveBodyFile(req.ContentType, redactedBody)
+	}
+	return req
+}
+
+// Speichert einen Body im Verzeichnis static_files_dir und liefert den Link auf die Datei
+func saveBodyFile(contentType string, body []byte) string {
+	// Generiere einen Dateinamen
+	// Erkenne die Dateiendung aus dem Content-Type, unbekannte Typen erhalten ".bin"
+	extension := ".bin"
+	if extensions, err := mime.ExtensionsByType(contentType); err != nil {
+		fmt.Println(err)
+	} else if len(extensions) > 0 {
+		extension = extensions[0]
+	}
+
+	filename := fmt.Sprintf("%s%s", generateRandomString(6), extension)
+
+	// Speichere den Body-Inhalt in static_files_dir, verschlüsselt falls ein Schlüssel konfiguriert ist
+	if err := writeStoredFile(dataPath(config.StaticFilesDir, filename), body); err != nil {
+		log.Println("Fehler beim Speichern des Body-Inhalts:", err)
 	}
+
+	// Liefere den Dateilink
+	return fmt.Sprintf("%s/static/%s", config.BaseURL, filename)
 }
 
 func generateRandomString(length int) string {
@@ -161,30 +226,65 @@ func generateRandomString(length int) string {
 	return string(result)
 }
 
-func handleTestRequest(forwardReqs chan<- Request) gin.HandlerFunc {
+func handleTestRequest() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Gebe "Hello World" unter dem Statuscode 200 aus
-		c.String(200, "Hello World\n")
-		c.String(200, "Hello Universe")
+		// Anfragen an /b/{bin}/... werden nur angenommen, wenn der Bin existiert
+		bin := binFromPath(c.Request.URL.Path)
+		if !binExists(bin) {
+			c.String(404, "Bin nicht gefunden")
+			return
+		}
 
 		// Rufe die saveRequest Methode mit einem in eine Struct umgewandelten Request
-		req := parseRequest(c)
-		forwardReqs <- req
+		req, rawBody, bodyContent := parseRequest(c)
+
+		if rule, ok := mockRuleByID(req.MockRuleID); ok {
+			// Eine passende Mock-Regel beantwortet die Anfrage, ohne den Upstream zu kontaktieren
+			req.Response = rule.Response.recorded()
+			writeRecordedResponse(c, req.Response)
+		} else if req.Spec != nil {
+			// Im Mock-Modus antwortet der Bin wie im OpenAPI-Dokument beschrieben
+			respondFromSpec(c, req)
+		} else if req.Validation != nil && req.Validation.Rejected {
+			// Verstößt der Body gegen eine Schema-Regel mit "reject", antworte mit den Fehlern
+			c.JSON(400, gin.H{"error": "Body entspricht nicht dem Schema", "violations": req.Validation.Violations})
+		} else if upstream := binUpstream(req.Bin); upstream != "" {
+			// Im Pass-Through-Modus wird die Anfrage an den Upstream weitergeleitet und dessen Antwort aufgezeichnet
+			req.Response = forwardToUpstream(c, upstream, rawBody)
+		} else {
+			// Gebe "Hello World" unter dem Statuscode 200 aus
+			c.String(200, "Hello World\n")
+			c.String(200, "Hello Universe")
+		}
+		req.Status = c.Writer.Status()
+
+		// Erst jetzt schwärzen, damit auch die aufgezeichnete Antwort erfasst wird
+		req = storeCapturedRequest(req, bodyContent)
+		recordCapture(req)
+
+		SendToAllClients(&req)
 		saveRequest(req)
+		enforceRetention()
 	}
 
 }
 
 // Gebe die Anzahl der Anfragen aus
 func requestCounter(c *gin.Context) {
-	fmt.Printf("Anzahl der Requests: %d\n", len(requests))
-	c.String(200, fmt.Sprintf("Anzahl der Requests: %d", len(requests)))
+	requestsMu.RLock()
+	count := len(requests)
+	requestsMu.RUnlock()
+
+	fmt.Printf("Anzahl der Requests: %d\n", count)
+	c.String(200, fmt.Sprintf("Anzahl der Requests: %d", count))
 }
 
 // Speichern einer Request-Struct an den Anfang einer Slice sowie in eine Datei
 func saveRequest(r Request) {
 	// Füge die Request-Struct der Slice hinzu
+	requestsMu.Lock()
 	requests = append([]Request{r}, requests...)
+	requestsMu.Unlock()
 
 	// Speichere die Request-Struct in eine Datei
 	saveToFile(r)
@@ -199,15 +299,122 @@ func saveToFile(r Request) {
 		return
 	}
 
-	// Speichern der Datei
-	err = os.WriteFile(fmt.Sprintf("./requests/%s.json", r.ID), data, 0644)
+	// Speichern der Datei, verschlüsselt falls ein Schlüssel konfiguriert ist
+	err = writeStoredFile(dataPath(config.RequestsDir, r.ID+".json"), data)
 	if err != nil {
 		log.Println("Fehler beim Schreiben der Datei:", err)
 	}
 }
 
-// Zeigt eine Liste von Requests basierend auf dem Query-Parameter "p" an.
+// Sucht eine gespeicherte Anfrage anhand ihrer ID
+func findRequest(id string) (Request, bool) {
+	requestsMu.RLock()
+	defer requestsMu.RUnlock()
+
+	for _, r := range requests {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Request{}, false
+}
+
+// Gibt eine gespeicherte Anfrage als JSON aus
+func viewRequest(c *gin.Context) {
+	req, ok := findRequest(c.Param("id"))
+	if !ok {
+		c.String(http.StatusNotFound, "Anfrage nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, req.Bin, false) {
+		return
+	}
+	c.JSON(http.StatusOK, req)
+}
+
+// Löscht eine gespeicherte Anfrage samt ihrer Dateien
+func deleteRequest(c *gin.Context) {
+	req, ok := findRequest(c.Param("id"))
+	if !ok {
+		c.String(http.StatusNotFound, "Anfrage nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, req.Bin, true) {
+		return
+	}
+
+	// Die Anfrage kann inzwischen durch die Aufbewahrungsregeln entfernt worden sein
+	found := false
+	requestsMu.Lock()
+	for i := range requests {
+		if requests[i].ID == req.ID {
+			requests = append(requests[:i], requests[i+1:]...)
+			found = true
+			break
+		}
+	}
+	requestsMu.Unlock()
+	if !found {
+		c.String(http.StatusNotFound, "Anfrage nicht gefunden")
+		return
+	}
+
+	removeRequestFiles(req)
+	broadcastRequestEvent(eventRequestDeleted, req, RequestDeletedEvent{ID: req.ID, Bin: req.Bin})
+	c.Status(http.StatusNoContent)
+}
+
+// Liest den in static_files_dir gespeicherten Body einer Anfrage. Anfragen ohne Datei liefern nil.
+func readRequestBody(r Request) ([]byte, error) {
+	if r.LinkToFile == "" {
+		return nil, nil
+	}
+	return readStoredFile(dataPath(config.StaticFilesDir, path.Base(r.LinkToFile)))
+}
+
+// Liefert eine Datei aus dem Verzeichnis static_files_dir aus und entschlüsselt sie bei Bedarf
+func serveStaticFile(c *gin.Context) {
+	name := filepath.Base(c.Param("filepath"))
+	data, err := readStoredFile(dataPath(config.StaticFilesDir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.String(http.StatusNotFound, "Datei nicht gefunden")
+			return
+		}
+		log.Println("Fehler beim Lesen der Datei:", err)
+		c.String(http.StatusInternalServerError, "Fehler beim Lesen der Datei")
+		return
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// Zeigt eine Liste von Requests eines Bins basierend auf den Query-Parametern "bin" und "p" bzw. "before" an.
 func viewRequests(c *gin.Context) {
+	// Query-Parameter 'bin' auslesen
+	bin, ok := binFromQuery(c)
+	if !ok {
+		return
+	}
+
+	// Filter über Methode, Pfad, Header, Body-Felder und Anmerkungen auslesen
+	filter, err := parseRequestFilter(c)
+	if err != nil {
+		c.String(400, "Ungültiger Filter: "+err.Error())
+		return
+	}
+	list := filterRequests(requestsInBin(bin), filter)
+
+	// Mit format=har werden alle Requests des Bins als HTTP Archive exportiert, mit 'p' nur die Seite
+	if c.Query("format") == "har" && c.Query("p") == "" {
+		exportHAR(c, bin, list)
+		return
+	}
+
 	// Query-Parameter 'p' auslesen
 	pageStr := c.DefaultQuery("p", "1")
 	page, err := strconv.Atoi(pageStr)
@@ -217,16 +424,54 @@ func viewRequests(c *gin.Context) {
 	}
 
 	// Anzahl der Requests pro Seite und Start-/Endindex berechnen
-	requestsPerPage := 10
+	requestsPerPage := config.PageSize
 	startIndex := (page - 1) * requestsPerPage
+
+	// Mit 'before' beginnt die Seite nach der Anfrage mit dieser ID. Anders als 'p' verschiebt sich
+	// die Seite dadurch nicht, wenn inzwischen neue Anfragen eingetroffen sind.
+	if before := c.Query("before"); before != "" {
+		startIndex = -1
+		for i, r := range list {
+			if r.ID == before {
+				startIndex = i + 1
+				break
+			}
+		}
+		if startIndex < 0 {
+			c.String(404, "Anfrage für 'before' nicht gefunden")
+			return
+		}
+	}
 	endIndex := startIndex + requestsPerPage
 
-	// Holen der gewünschten Anzahl von Requests aus der Slice
-	currentRequestSlice := getSliceElements(requests, startIndex, endIndex)
-	c.Header("Access-Control-Allow-Origin", "*")
+	// Holen der gewünschten Anzahl von Requests des Bins aus der Slice
+	currentRequestSlice := getSliceElements(list, startIndex, endIndex)
+
+	// Gibt es eine weitere Seite, steht ihr Cursor für 'before' im Header
+	if endIndex < len(list) && len(currentRequestSlice) > 0 {
+		c.Header("X-Next-Before", currentRequestSlice[len(currentRequestSlice)-1].ID)
+	}
+	if c.Query("format") == "har" {
+		exportHAR(c, bin, currentRequestSlice)
+		return
+	}
 	c.JSON(200, currentRequestSlice)
 }
 
+// Liefert alle Requests eines Bins, neueste zuerst
+func requestsInBin(bin string) []Request {
+	requestsMu.RLock()
+	defer requestsMu.RUnlock()
+
+	result := []Request{}
+	for _, r := range requests {
+		if r.Bin == bin {
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
 func getSliceElements(slice []Request, start, end int) []Request {
 	if start < 0 || start > end || start >= len(slice) {
 		return []Request{}
@@ -242,51 +487,125 @@ func getSliceElements(slice []Request, start, end int) []Request {
 // Funktion zum Anlegen des Verzeichnisses
 // Legt die Datei requests an: Home/GolandProject/awesomeProjects
 func createRequestsDirectory() error {
-	return os.MkdirAll("./requests", 0755) // 0755 sind die Berechtigungen
+	return os.MkdirAll(dataPath(config.RequestsDir), 0755) // 0755 sind die Berechtigungen
+}
+
+// Legt das Verzeichnis für die Bodies an
+func createStaticFilesDirectory() error {
+	return os.MkdirAll(dataPath(config.StaticFilesDir), 0755)
 }
 
 // Zusatzaufgabe 2: Echtzeitkommunikation mit dem Browser (Websockets oder SSE)
 
-// Diese Funktion akzeptiert eine Request-Struktur und sendet sie an alle registrierten SSE-Clients.
-func SendToAllClients(req Request) {
-	// Wandel das Request in json um
-	var data, err = json.Marshal(req)
-	if err == nil {
-		// Fülle den allClients chan mit dem String des Requests
-		for clientId, client := range SSEClients {
-			fmt.Printf("Sending to client %s", clientId)
-			dataString := fmt.Sprintf("event: message\ndata: %s\n\n", string(data))
-			client <- string(dataString)
-		}
+// Diese Funktion akzeptiert eine Request-Struktur und sendet sie als "request.created" an alle SSE-Clients,
+// die den Bin des Requests abonniert haben.
+// Die Nummer des Ereignisses wird in req.Seq übernommen, damit sie mit der Anfrage gespeichert wird.
+func SendToAllClients(req *Request) {
+	r := *req
+	e := sseEvent{Bin: req.Bin, Name: eventRequestCreated, Request: &r}
+	e.loadDocument()
+
+	eventLogMu.Lock()
+	defer eventLogMu.Unlock()
+
+	req.Seq = nextEventID()
+	r.Seq = req.Seq
+	data, err := json.Marshal(req)
+	if err != nil {
+		log.Println("Fehler beim Marshalling des Requests:", err)
+		return
 	}
+	e.ID, e.Data = req.Seq, data
+	emitEvent(e)
 }
 
-// Musste global angelegt werden
-var SSEClients = make(map[string]chan string)
+// Sendet ein Ereignis mit dem als JSON kodierten payload an alle SSE-Clients des Bins.
+// Mit einem leeren Bin erhalten alle Clients das Ereignis.
+func broadcastEvent(bin, event string, payload interface{}) {
+	publishEvent(sseEvent{Bin: bin, Name: event}, payload)
+}
+
+// Sendet ein Ereignis zu einer Anfrage nur an die Clients, deren Filter auf die Anfrage passt
+func broadcastRequestEvent(event string, req Request, payload interface{}) {
+	publishEvent(sseEvent{Bin: req.Bin, Name: event, Request: &req}, payload)
+}
 
-// Wird mit einer bestimmten Anzahl an Requests aufgerufen und sendet diese an alle Klienten aus SSEClients
-func reciver(requests <-chan Request) {
-	for req := range requests {
-		// Fülle den SSEClients chan mit dem String des Requests
-		SendToAllClients(req)
+func publishEvent(e sseEvent, payload interface{}) {
+	// Wandel den Payload in json um
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Println("Fehler beim Marshalling des Ereignisses:", err)
+		return
 	}
+	e.Data = data
+	e.loadDocument()
+
+	eventLogMu.Lock()
+	defer eventLogMu.Unlock()
+	e.ID = nextEventID()
+	emitEvent(e)
 }
 
-func SSEHandler(requestsChan <-chan Request) gin.HandlerFunc {
+// Ein verbundener SSE-Client mit dem Bin, dessen Anfragen er erhält, seinem Filter und seiner Warteschlange
+type SSEClient struct {
+	bin    string
+	filter requestFilter
+	queue  *sseQueue
+}
+
+// Musste global angelegt werden
+var SSEClients = make(map[string]SSEClient)
+
+// Schützt die Map SSEClients vor gleichzeitigem Zugriff
+var SSEClientsMu sync.RWMutex
 
-	// Fülle die SSEClients für alle Requests
-	go reciver(requestsChan)
+// Abstand der Kommentarzeilen, die eine ruhende Verbindung durch Proxys hindurch offen halten
+const sseHeartbeatInterval = 15 * time.Second
 
+func SSEHandler() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		clientChannel := make(chan string)
+		// Der Client erhält nur Anfragen des per Query-Parameter "bin" gewählten Bins
+		bin, ok := binFromQuery(c)
+		if !ok {
+			return
+		}
+
+		// Der Client erhält nur Anfragen, die auf den Filter passen. Die Syntax ist dieselbe wie bei /view-requests.
+		filter, err := parseRequestFilter(c)
+		if err != nil {
+			c.String(http.StatusBadRequest, "Ungültiger Filter: "+err.Error())
+			return
+		}
+
+		// Ein wiederverbundener Client erhält die verpassten Ereignisse
+		lastID, resume, err := lastEventID(c.GetHeader("Last-Event-ID"), c.Query("last_event_id"))
+		if err != nil {
+			c.String(http.StatusBadRequest, "Ungültige Last-Event-ID")
+			return
+		}
+
+		// Verpasste Ereignisse und Anmeldung unter derselben Sperre, damit kein Ereignis fehlt oder doppelt ankommt
+		queue := newSSEQueue(config.SSEQueueSize, config.SSEOverflowPolicy)
 		clientId := generateRandomString(50)
-		SSEClients[clientId] = clientChannel
+		// start ist die Nummer, ab der der Client Ereignisse erhält. Sie wird ihm gleich mitgeteilt, damit er
+		// auch dann per Last-Event-ID wieder aufsetzt, wenn die Verbindung vor dem ersten Ereignis abbricht.
+		var missed []sseEvent
+		start := lastID
+		eventLogMu.Lock()
+		if resume {
+			missed = missedEvents(bin, lastID, filter)
+		} else {
+			start = eventSeq
+		}
+		SSEClientsMu.Lock()
+		SSEClients[clientId] = SSEClient{bin: bin, filter: filter, queue: queue}
+		SSEClientsMu.Unlock()
+		eventLogMu.Unlock()
 		fmt.Println("Client connected: ", clientId)
 		// Set the response headers for SSE
 		c.Header("Content-Type", "text/event-stream")
 		c.Header("Cache-Control", "no-cache")
 		c.Header("Connection", "keep-alive")
-		c.Header("Access-Control-Allow-Origin", "*")
 
 		flusher := c.Writer.(http.Flusher)
 		flusher.Flush()
@@ -299,23 +618,72 @@ func SSEHandler(requestsChan <-chan Request) gin.HandlerFunc {
 
 		closeNotify := closeNotifier.CloseNotify()
 
+		// Schreibvorgänge an einen hängenden Client werden nach sseWriteTimeout abgebrochen.
+		// Soll der Client wegen einer vollen Warteschlange getrennt werden, bricht ein laufender Schreibvorgang sofort ab.
+		controller := http.NewResponseController(c.Writer)
+		handlerDone := make(chan struct{})
+		go func() {
+			select {
+			case <-queue.kicked:
+				controller.SetWriteDeadline(time.Now())
+			case <-handlerDone:
+			}
+		}()
+
 		defer func() {
+			close(handlerDone)
+			SSEClientsMu.Lock()
 			delete(SSEClients, clientId)
-			close(clientChannel)
-			fmt.Println("Client disconnected:", clientId)
+			SSEClientsMu.Unlock()
+			fmt.Println("Client disconnected:", clientId, "verworfene Nachrichten:", queue.droppedCount())
 		}()
 
+		// Zuerst die Wartezeit für Wiederverbindungen, die Startnummer und die verpassten Ereignisse senden.
+		// Ein id-Feld ohne data setzt bei EventSource nur die Last-Event-ID.
+		controller.SetWriteDeadline(time.Now().Add(sseWriteTimeout))
+		if _, err := fmt.Fprintf(c.Writer, "retry: %d\nid: %d\n\n", sseRetryMillis, start); err != nil {
+			return
+		}
+		for _, e := range missed {
+			if _, err := fmt.Fprint(c.Writer, e.format()); err != nil {
+				fmt.Println("Fehler beim Schreiben an den Client:", clientId, err)
+				return
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+
+		heartbeat := time.NewTicker(sseHeartbeatInterval)
+		defer heartbeat.Stop()
+
 		// Continuously write messages to the client
 		for {
 			select {
-			case message, ok := <-clientChannel:
-				if !ok {
-					fmt.Println("Client channel closed:", clientId)
+			case <-heartbeat.C:
+				// Kommentarzeilen werden von EventSource ignoriert
+				controller.SetWriteDeadline(time.Now().Add(sseWriteTimeout))
+				if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				if err := controller.Flush(); err != nil {
+					return
+				}
+			case <-queue.notify:
+				controller.SetWriteDeadline(time.Now().Add(sseWriteTimeout))
+				for _, e := range queue.drain() {
+					if _, err := fmt.Fprint(c.Writer, e.format()); err != nil {
+						fmt.Println("Fehler beim Schreiben an den Client:", clientId, err)
+						return
+					}
+				}
+				if err := controller.Flush(); err != nil {
 					return
 				}
-				fmt.Println("Relaying info via SSE")
-				fmt.Fprintf(c.Writer, "%s\n", message)
-				flusher.Flush()
+			case <-queue.kicked:
+				// Der Client kommt mit den Nachrichten nicht hinterher und wird getrennt
+				fmt.Println("Client zu langsam, Verbindung wird getrennt:", clientId)
+				return
 			case <-closeNotify:
 				return
 			}
@@ -329,24 +697,74 @@ func fourRoot(x float64) float64 {
 	return result
 }
 
-func main() {
-
-	// Verzeichnis "./requests" anlegen, falls es nicht existiert
+// Legt die Verzeichnisse an und lädt alle gespeicherten Daten gemäß config.
+// Muss vor dem Erstellen der Router aufgerufen werden.
+func Setup() error {
+	// Verzeichnisse requests_dir und static_files_dir anlegen, falls sie nicht existieren
 	if err := createRequestsDirectory(); err != nil {
-		log.Fatal("Fehler beim Anlegen des Verzeichnisses:", err)
+		return fmt.Errorf("Fehler beim Anlegen des Verzeichnisses: %w", err)
+	}
+	if err := createStaticFilesDirectory(); err != nil {
+		return fmt.Errorf("Fehler beim Anlegen des Verzeichnisses: %w", err)
+	}
+
+	// Verzeichnis "bins" anlegen, falls es nicht existiert
+	if err := createBinsDirectory(); err != nil {
+		return fmt.Errorf("Fehler beim Anlegen des Verzeichnisses: %w", err)
+	}
+
+	// Verzeichnis "tokens" anlegen, falls es nicht existiert
+	if err := createTokensDirectory(); err != nil {
+		return fmt.Errorf("Fehler beim Anlegen des Verzeichnisses: %w", err)
 	}
 
-	restoreRequests() // Anfragen einmal beim Programmstart laden
+	restoreBins()   // Bins einmal beim Programmstart laden
+	restoreTokens() // API-Tokens einmal beim Programmstart laden
 
+	// Schlüssel für verschlüsselte Daten laden und Verzeichnis "unredacted" anlegen
+	if err := loadEncryptionKey(); err != nil {
+		return fmt.Errorf("Fehler beim Laden des Schlüssels: %w", err)
+	}
+	if err := createUnredactedDirectory(); err != nil {
+		return fmt.Errorf("Fehler beim Anlegen des Verzeichnisses: %w", err)
+	}
+	restoreRedactionRules() // Schwärzungsregeln einmal beim Programmstart laden
+
+	// Verzeichnis "schemas" anlegen, falls es nicht existiert
+	if err := createSchemasDirectory(); err != nil {
+		return fmt.Errorf("Fehler beim Anlegen des Verzeichnisses: %w", err)
+	}
+	restoreSchemaRules() // Schema-Regeln einmal beim Programmstart laden
+
+	// Verzeichnis "openapi" anlegen, falls es nicht existiert
+	if err := createOpenAPIDirectory(); err != nil {
+		return fmt.Errorf("Fehler beim Anlegen des Verzeichnisses: %w", err)
+	}
+	restoreOpenAPISpecs() // OpenAPI-Dokumente der Bins im Mock-Modus laden
+
+	// Verzeichnis "mocks" anlegen, falls es nicht existiert
+	if err := createMocksDirectory(); err != nil {
+		return fmt.Errorf("Fehler beim Anlegen des Verzeichnisses: %w", err)
+	}
+	restoreMockRules()      // Mock-Regeln einmal beim Programmstart laden
+	restoreScenarioStates() // Zustände der Szenarien laden
+
+	restoreRequests()      // Anfragen einmal beim Programmstart laden
+	restoreEventSequence() // Nummerierung der SSE-Ereignisse fortsetzen
+
+	// Grenzen für die Aufbewahrung sofort anwenden
+	enforceRetention()
+	return nil
+}
+
+// Erstellt den Router für den Capture-Port, der alle Anfragen aufzeichnet
+func NewCaptureRouter() *gin.Engine {
 	// Default Instanz der Gin-Engine erstellen
 	router := gin.Default()
 
 	//http.HandleFunc("/sse", sseHandler)
 	//http.ListenAndServe(":8080", nil)
 
-	var messageChan = make(chan Request)
-	defer close(messageChan)
-
 	// Admin interface endpoint
 	//requests2 := make(chan Request)
 	//router.GET("/admin", AdminHandler(requests2))
@@ -354,29 +772,168 @@ func main() {
 	// Der Server soll auf der URL /requests auf alle Anfragen mit der Methode: requestCounter reagieren
 	router.Any("/requests", requestCounter)
 
-	// Serve static files from the "static-files" directory
-	router.StaticFS("/static", http.Dir("./static-files"))
+	// Serve static files from the static_files_dir directory, decrypted if necessary
+	router.GET("/static/*filepath", serveStaticFile)
+	router.HEAD("/static/*filepath", serveStaticFile)
+
+	// Der Server soll auf allen URL-Endpunkten mit der Methode handleTestRequest reagieren.
+	// Anfragen an /b/{bin}/... werden dabei im jeweiligen Bin gespeichert.
+	router.Use(observeLatency("capture"), handleTestRequest())
 
-	// Der Server soll auf allen URL-Endpunkten mit der Methode handleTestRequest reagieren
-	router.Use(handleTestRequest(messageChan))
+	return router
+}
+
+// Erstellt den Router der Management-API einschließlich SSE und WebSocket
+func NewManagementRouter() *gin.Engine {
+	// Zweite Instanz der Gin-Engine erstellen: Management-API.
+	// Wie gin.Default, aber das Access-Log maskiert Tokens in der URL.
+	managementRouter := gin.New()
+	managementRouter.Use(accessLog(), gin.Recovery(), corsHeaders, observeLatency("management"))
+
+	// Alle Routen der Management-API erfordern ein gültiges Token im Authorization-Header
+	api := managementRouter.Group("/", authenticate(false))
 
-	// Zweite Default Instanz der Gin-Engine erstellen: Management-API
-	managementRouter := gin.Default()
 	// Der Server soll auf der URL /view-requests auf Get Anfragen mit der Methode: viewRequests reagieren
+	api.GET("/view-requests", viewRequests)
+
+	// Import von Anfragen aus einem HTTP Archive (Export über /view-requests?format=har)
+	api.POST("/requests/import", importHAR)
+
+	// Gespeicherte Anfrage als curl-, HTTPie-, Go-, Python- oder fetch-Snippet
+	api.GET("/requests/:id/snippet", viewRequestSnippet)
+
+	// Vergleich zweier gespeicherter Anfragen
+	api.GET("/requests/diff", viewRequestDiff)
+
+	// Auf die nächsten passenden Anfragen warten, z. B. in Integrationstests
+	api.GET("/requests/wait", waitForRequests)
+
+	// Einzelne Anfrage abrufen
+	api.GET("/requests/:id", viewRequest)
+
+	// Tags, Notiz und Markierung einer Anfrage ändern
+	api.PATCH("/requests/:id", updateRequestAnnotations)
+
+	// Gespeicherte Anfrage erneut an ein Ziel senden
+	api.POST("/requests/:id/replay", requireAdmin, replayRequest)
+
+	// Einzelne Anfragen löschen
+	api.DELETE("/requests/:id", deleteRequest)
+
+	// Statistiken über die Anfragen eines Bins
+	api.GET("/stats", viewStats)
+
+	// Metriken im Textformat von Prometheus (nur für Admins)
+	api.GET("/metrics", requireAdmin, serveMetrics)
+
+	// Wirksame Konfiguration mit maskierten Geheimnissen (nur für Admins)
+	api.GET("/config", requireAdmin, viewConfig)
+
+	// Verwaltung der Bins
+	api.GET("/bins", listBins)
+	api.POST("/bins", requireAdmin, createBin)
+	api.DELETE("/bins/:bin", requireAdmin, deleteBin)
+	api.DELETE("/bins/:bin/requests", clearBin)
+	api.PUT("/bins/:bin/signature", updateBinSignature)
+
+	// Mock-Modus eines Bins anhand eines OpenAPI-Dokuments
+	api.GET("/bins/:bin/openapi", viewBinOpenAPI)
+	api.PUT("/bins/:bin/openapi", updateBinOpenAPI)
+	api.DELETE("/bins/:bin/openapi", deleteBinOpenAPI)
+
+	// Pass-Through-Modus: Anfragen an einen Upstream weiterleiten und die Antworten aufzeichnen
+	api.PUT("/bins/:bin/upstream", requireAdmin, updateBinUpstream)
+
+	// Mock-Regeln und Fixtures aus aufgezeichneten Anfragen
+	api.GET("/mocks", listMockRules)
+	api.POST("/mocks", createMockRule)
+	api.PUT("/mocks/:id", updateMockRule)
+	api.DELETE("/mocks/:id", deleteMockRule)
+	api.POST("/mocks/from-requests", createMockRulesFromRequests)
+	api.GET("/mocks/export", exportMockRules)
+	api.POST("/mocks/import", importMockRules)
+
+	// Szenarien für zustandsbehaftete Mock-Regeln
+	api.GET("/scenarios", listScenarios)
+	api.POST("/scenarios/reset", resetAllScenarios)
+	api.PUT("/scenarios/:name", updateScenario)
+	api.POST("/scenarios/:name/reset", resetScenario)
+
+	// JSON-Schema-Regeln für eingehende Anfragen
+	api.GET("/schemas", listSchemaRules)
+	api.POST("/schemas", createSchemaRule)
+	api.DELETE("/schemas/:id", deleteSchemaRule)
+
+	// Verwaltung der API-Tokens, nur für Admins
+	admin := api.Group("/tokens", requireAdmin)
+	admin.GET("", listTokens)
+	admin.POST("", createToken)
+	admin.DELETE("/:id", deleteToken)
+
+	// Schwärzungsregeln und ungeschwärzte Originale, nur für Admins
+	api.GET("/redaction", requireAdmin, viewRedactionRules)
+	api.PUT("/redaction", requireAdmin, updateRedactionRules)
+	api.GET("/requests/:id/unredacted", requireAdmin, viewUnredactedRequest)
+
+	// Füge die SSE-Route hinzu. EventSource kann keine Header setzen, daher ist hier auch ?token= erlaubt.
+	managementRouter.GET("/sse", authenticate(true), SSEHandler())
+
+	// Dieselben Ereignisse über WebSocket, zusätzlich mit Befehlen des Clients
+	managementRouter.GET("/ws", authenticate(true), websocketHandler)
+
+	return managementRouter
+}
+
+// Startet beide Server auf capture_addr und management_addr (Standard 8080 und 8081).
+// Einstiegspunkt des Programms in cmd/inspector für "serve" und "reencrypt", die Optionen folgen auf den Befehl.
+func Main() {
+	args := os.Args[1:]
+	command := "serve"
+	if len(args) > 0 && (args[0] == "serve" || args[0] == "reencrypt") {
+		command, args = args[0], args[1:]
+	}
+
+	// Standardwerte, Konfigurationsdatei, Umgebungsvariablen und Optionen
+	cfg, err := LoadConfig(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal("Ungültige Konfiguration:\n", err)
+	}
+	config = cfg
+
+	// Befehl "reencrypt": alle gespeicherten Dateien mit einem neuen Schlüssel verschlüsseln und beenden
+	if command == "reencrypt" {
+		if err := loadEncryptionKey(); err != nil {
+			log.Fatal("Fehler beim Laden des Schlüssels:", err)
+		}
+		if err := reencryptStorage(); err != nil {
+			log.Fatal("Fehler beim Neuverschlüsseln:", err)
+		}
+		return
+	}
+
+	if err := Setup(); err != nil {
+		log.Fatal(err)
+	}
+	startRetention()
+
+	router := NewCaptureRouter()
+	managementRouter := NewManagementRouter()
 
-	managementRouter.GET("/view-requests", viewRequests)
-	// Füge die SSE-Route hinzu
-	managementRouter.GET("/sse", SSEHandler(messageChan))
+	// Beim Beenden erhalten die SSE-Clients das Ereignis server.shutdown
+	handleShutdownSignals()
 
-	// Hier wird der HTTP-Server mit dem Router managementRouter gestartet und auf dem Port 8081 gehostet.
+	// Hier wird der HTTP-Server mit dem Router managementRouter gestartet und auf management_addr gehostet.
 	go func() {
-		if err := managementRouter.Run(":8081"); err != nil {
+		if err := managementRouter.Run(config.ManagementAddr); err != nil {
 			log.Fatal(err)
 		}
 	}()
 
-	// Hier wird der HTTP-Server mit dem Router managementRouter gestartet und auf dem Port 8080 gehostet.
-	if err := router.Run(":8080"); err != nil {
+	// Hier wird der HTTP-Server mit dem Router router gestartet und auf capture_addr gehostet.
+	if err := router.Run(config.CaptureAddr); err != nil {
 		log.Fatal(err)
 	}
 
@@ -0,0 +1,220 @@
+package inspector
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Grenzen der Latenz-Histogramme in Sekunden, wie die Standard-Buckets der Prometheus-Clients
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Ein Histogramm mit kumulativ ausgegebenen Buckets
+type histogramMetric struct {
+	counts []uint64 // je Bucket, nicht kumulativ
+	sum    float64
+	count  uint64
+}
+
+func (h *histogramMetric) observe(v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(latencyBuckets))
+	}
+	for i, le := range latencyBuckets {
+		if v <= le {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// Trennt die Label-Werte in den Schlüsseln der Metriken
+const metricsLabelSplitter = "\x00"
+
+// Die gesammelten Metriken. Zähler und Histogramme sind nach ihren Label-Werten indiziert.
+var (
+	metricsMu          sync.Mutex
+	capturedRequests   = make(map[string]uint64)           // bin, method, status
+	capturedBodyBytes  = make(map[string]uint64)           // bin
+	retentionEvictions = make(map[string]uint64)           // bin
+	sseDroppedMessages = make(map[string]uint64)           // policy
+	handlerLatency     = make(map[string]*histogramMetric) // server, handler
+)
+
+// Zählt eine aufgezeichnete Anfrage
+func recordCapture(r Request) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	capturedRequests[strings.Join([]string{r.Bin, r.Method, strconv.Itoa(r.Status)}, metricsLabelSplitter)]++
+	capturedBodyBytes[r.Bin] += uint64(r.BodySize)
+}
+
+// Zählt die durch die Aufbewahrungsgrenzen entfernten Anfragen
+func recordEvictions(evicted []Request) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	for _, r := range evicted {
+		retentionEvictions[r.Bin]++
+	}
+}
+
+// Zählt eine SSE-Nachricht, die wegen einer vollen Warteschlange einen Client nicht erreicht hat
+func recordSSEDrop(policy string) {
+	metricsMu.Lock()
+	sseDroppedMessages[policy]++
+	metricsMu.Unlock()
+}
+
+// Middleware, die die Bearbeitungszeit jeder Anfrage misst.
+// Auf dem Capture-Port wird nicht nach Route unterschieden, damit beliebige Pfade keine neuen Zeitreihen erzeugen.
+func observeLatency(server string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		handler := "capture"
+		if server != "capture" {
+			handler = c.FullPath()
+			if handler == "" {
+				handler = "unmatched"
+			}
+		}
+
+		metricsMu.Lock()
+		key := server + metricsLabelSplitter + handler
+		h, ok := handlerLatency[key]
+		if !ok {
+			h = &histogramMetric{}
+			handlerLatency[key] = h
+		}
+		h.observe(time.Since(start).Seconds())
+		metricsMu.Unlock()
+	}
+}
+
+// Maskiert einen Label-Wert nach dem Prometheus-Textformat
+func escapeLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return strings.ReplaceAll(v, `"`, `\"`)
+}
+
+// Formatiert Label-Namen und die mit metricsLabelSplitter verbundenen Werte als {name="wert",...}
+func formatLabels(names []string, key string) string {
+	values := strings.Split(key, metricsLabelSplitter)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf(`%s="%s"`, name, escapeLabel(values[i]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// Liefert die Schlüssel einer Map sortiert, damit die Ausgabe stabil bleibt
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Ermittelt die Größe aller Dateien eines Verzeichnisses in Bytes
+func directorySize(dir string) int64 {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println("Fehler beim Ermitteln der Verzeichnisgröße:", err)
+	}
+	return size
+}
+
+// Gibt die Metriken im Textformat von Prometheus aus
+func serveMetrics(c *gin.Context) {
+	var b strings.Builder
+	header := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	// Werte, die beim Abruf ermittelt werden
+	stored := make(map[string]uint64)
+	requestsMu.RLock()
+	for _, r := range requests {
+		stored[r.Bin]++
+	}
+	requestsMu.RUnlock()
+
+	SSEClientsMu.RLock()
+	clients := len(SSEClients)
+	SSEClientsMu.RUnlock()
+
+	metricsMu.Lock()
+	header("inspector_captured_requests_total", "counter", "Aufgezeichnete Anfragen nach Bin, Methode und Status.")
+	for _, key := range sortedKeys(capturedRequests) {
+		fmt.Fprintf(&b, "inspector_captured_requests_total%s %d\n", formatLabels([]string{"bin", "method", "status"}, key), capturedRequests[key])
+	}
+
+	header("inspector_captured_body_bytes_total", "counter", "Summe der Body-Größen aufgezeichneter Anfragen in Bytes.")
+	for _, key := range sortedKeys(capturedBodyBytes) {
+		fmt.Fprintf(&b, "inspector_captured_body_bytes_total%s %d\n", formatLabels([]string{"bin"}, key), capturedBodyBytes[key])
+	}
+
+	header("inspector_retention_evictions_total", "counter", "Durch die Aufbewahrungsgrenzen entfernte Anfragen.")
+	for _, key := range sortedKeys(retentionEvictions) {
+		fmt.Fprintf(&b, "inspector_retention_evictions_total%s %d\n", formatLabels([]string{"bin"}, key), retentionEvictions[key])
+	}
+
+	header("inspector_sse_dropped_messages_total", "counter", "SSE-Nachrichten, die wegen einer vollen Warteschlange verworfen wurden, nach Überlaufverhalten.")
+	for _, key := range sortedKeys(sseDroppedMessages) {
+		fmt.Fprintf(&b, "inspector_sse_dropped_messages_total%s %d\n", formatLabels([]string{"policy"}, key), sseDroppedMessages[key])
+	}
+
+	header("inspector_http_request_duration_seconds", "histogram", "Bearbeitungszeit der Anfragen nach Server und Route.")
+	for _, key := range sortedKeys(handlerLatency) {
+		h := handlerLatency[key]
+		labels := formatLabels([]string{"server", "handler"}, key)
+		base := strings.TrimSuffix(labels, "}")
+		var cumulative uint64
+		for i, le := range latencyBuckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(&b, "inspector_http_request_duration_seconds_bucket%s,le=\"%s\"} %d\n", base, strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&b, "inspector_http_request_duration_seconds_bucket%s,le=\"+Inf\"} %d\n", base, h.count)
+		fmt.Fprintf(&b, "inspector_http_request_duration_seconds_sum%s %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "inspector_http_request_duration_seconds_count%s %d\n", labels, h.count)
+	}
+	metricsMu.Unlock()
+
+	header("inspector_stored_requests", "gauge", "Aktuell gespeicherte Anfragen nach Bin.")
+	for _, key := range sortedKeys(stored) {
+		fmt.Fprintf(&b, "inspector_stored_requests%s %d\n", formatLabels([]string{"bin"}, key), stored[key])
+	}
+
+	header("inspector_storage_bytes", "gauge", "Belegter Speicher auf dem Datenträger nach Verzeichnis.")
+	for _, dir := range encryptedDirectories() {
+		fmt.Fprintf(&b, "inspector_storage_bytes%s %d\n", formatLabels([]string{"directory"}, filepath.Base(dir)), directorySize(dir))
+	}
+
+	header("inspector_sse_clients", "gauge", "Verbundene Clients für Ereignisse über SSE, WebSocket und /requests/wait.")
+	fmt.Fprintf(&b, "inspector_sse_clients %d\n", clients)
+
+	c.Data(200, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
@@ -0,0 +1,510 @@
+package inspector
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Eine Mock-Regel beantwortet passende Anfragen eines Bins mit einer festen Antwort,
+// ohne den Upstream zu kontaktieren. BodyFields enthält Pfade im Body ("order.id" bzw. Formularfelder)
+// mit den Werten, die die Anfrage haben muss.
+// Gehört die Regel zu einem Szenario, feuert sie nur im Zustand RequiredState (leer für jeden Zustand)
+// und versetzt das Szenario anschließend in den Zustand NewState.
+type MockRule struct {
+	ID              string                 `json:"id"`
+	Bin             string                 `json:"bin"`
+	Method          string                 `json:"method,omitempty"` // leer für alle Methoden
+	Path            string                 `json:"path"`             // Pfadmuster wie bei den Schema-Regeln
+	BodyFields      map[string]interface{} `json:"body_fields,omitempty"`
+	Scenario        string                 `json:"scenario,omitempty"`
+	RequiredState   string                 `json:"required_state,omitempty"`
+	NewState        string                 `json:"new_state,omitempty"`
+	Response        MockResponse           `json:"response"`
+	SourceRequestID string                 `json:"source_request_id,omitempty"`
+	CreatedAt       time.Time              `json:"created_at"`
+}
+
+// Die Antwort einer Mock-Regel. Binäre Bodies werden Base64-kodiert abgelegt.
+type MockResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	Base64  bool              `json:"base64,omitempty"`
+}
+
+// Eine exportierte Sammlung von Mock-Regeln, z. B. als Fixture für CI
+type FixtureSet struct {
+	Version    int        `json:"version"`
+	Bin        string     `json:"bin"`
+	ExportedAt time.Time  `json:"exported_at"`
+	Rules      []MockRule `json:"rules"`
+}
+
+// Alle Mock-Regeln, nach ID indiziert
+var (
+	mockRules   = make(map[string]MockRule)
+	mockRulesMu sync.RWMutex
+)
+
+// Legt das Verzeichnis ./mocks an, in dem jede Mock-Regel als eigene Datei gespeichert wird
+func createMocksDirectory() error {
+	return os.MkdirAll(dataPath("mocks"), 0755)
+}
+
+// Lese alle Mock-Regeln aus dem Verzeichnis ./mocks
+func restoreMockRules() {
+	entries, err := os.ReadDir(dataPath("mocks"))
+	if err != nil {
+		log.Fatal("Fehler beim Lesen der Mock-Regeln:", err)
+	}
+
+	mockRulesMu.Lock()
+	defer mockRulesMu.Unlock()
+
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), reencryptTempSuffix) {
+			continue
+		}
+		data, err := readStoredFile(dataPath("mocks", entry.Name()))
+		if err != nil {
+			log.Println("Fehler beim Lesen der Datei:", err)
+			continue
+		}
+
+		var rule MockRule
+		if err := json.Unmarshal(data, &rule); err != nil {
+			log.Println("Fehler beim Entmarshalling der Mock-Regel:", err)
+			continue
+		}
+		mockRules[rule.ID] = rule
+	}
+}
+
+// Speichere eine Mock-Regel in die Datei ./mocks/{id}.json. Die aufgezeichnete Antwort kann sensible Daten
+// enthalten, daher wird wie bei den Anfragen verschlüsselt, falls ein Schlüssel konfiguriert ist.
+func saveMockRuleToFile(rule MockRule) {
+	data, err := json.MarshalIndent(rule, "", "    ")
+	if err != nil {
+		log.Println("Fehler beim Marshalling der Mock-Regel:", err)
+		return
+	}
+
+	if err := writeStoredFile(dataPath("mocks", rule.ID+".json"), data); err != nil {
+		log.Println("Fehler beim Schreiben der Datei:", err)
+	}
+}
+
+// Speichert eine neue Mock-Regel im Speicher und auf dem Datenträger
+func addMockRule(rule MockRule) MockRule {
+	rule.ID = uuid.New().String()
+	rule.CreatedAt = time.Now()
+
+	mockRulesMu.Lock()
+	mockRules[rule.ID] = rule
+	mockRulesMu.Unlock()
+	saveMockRuleToFile(rule)
+	return rule
+}
+
+// Entfernt eine Mock-Regel aus dem Speicher und vom Datenträger
+func removeMockRule(id string) {
+	mockRulesMu.Lock()
+	delete(mockRules, id)
+	mockRulesMu.Unlock()
+
+	if err := os.Remove(dataPath("mocks", id+".json")); err != nil && !os.IsNotExist(err) {
+		log.Println("Fehler beim Löschen der Datei:", err)
+	}
+}
+
+// Sucht eine Mock-Regel anhand ihrer ID
+func mockRuleByID(id string) (MockRule, bool) {
+	if id == "" {
+		return MockRule{}, false
+	}
+
+	mockRulesMu.RLock()
+	defer mockRulesMu.RUnlock()
+	rule, ok := mockRules[id]
+	return rule, ok
+}
+
+// Entfernt alle Mock-Regeln eines gelöschten Bins
+func deleteMockRulesForBin(bin string) {
+	for _, rule := range mockRulesInBin(bin) {
+		removeMockRule(rule.ID)
+	}
+}
+
+// Liefert alle Mock-Regeln eines Bins, älteste zuerst
+func mockRulesInBin(bin string) []MockRule {
+	mockRulesMu.RLock()
+	list := []MockRule{}
+	for _, rule := range mockRules {
+		if rule.Bin == bin {
+			list = append(list, rule)
+		}
+	}
+	mockRulesMu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
+
+// Prüft die Angaben einer Mock-Regel
+func (r *MockRule) validate() error {
+	if r.Path == "" {
+		return fmt.Errorf("Pfad fehlt")
+	}
+	if r.Scenario == "" && (r.RequiredState != "" || r.NewState != "") {
+		return fmt.Errorf("Zustände sind nur mit einem Szenario möglich")
+	}
+	if r.Response.Status == 0 {
+		r.Response.Status = http.StatusOK
+	}
+	if r.Response.Status < 100 || r.Response.Status > 599 {
+		return fmt.Errorf("ungültiger Statuscode %d", r.Response.Status)
+	}
+	if r.Response.Base64 {
+		if _, err := base64.StdEncoding.DecodeString(r.Response.Body); err != nil {
+			return fmt.Errorf("Body ist nicht Base64-kodiert")
+		}
+	}
+	return nil
+}
+
+// Wandelt die Antwort einer Mock-Regel in eine RecordedResponse um
+func (m MockResponse) recorded() *RecordedResponse {
+	body := []byte(m.Body)
+	if m.Base64 {
+		body, _ = base64.StdEncoding.DecodeString(m.Body)
+	}
+
+	headers := make(http.Header, len(m.Headers))
+	for name, value := range m.Headers {
+		headers.Set(name, value)
+	}
+	return &RecordedResponse{Status: m.Status, Headers: headers, Body: body}
+}
+
+// Wandelt eine aufgezeichnete Antwort in die Antwort einer Mock-Regel um
+func mockResponseFrom(r *RecordedResponse) MockResponse {
+	m := MockResponse{Status: r.Status, Headers: make(map[string]string, len(r.Headers))}
+	for name := range r.Headers {
+		// Ein aufgezeichnetes Datum wäre bei jeder Wiedergabe veraltet
+		if name == "Date" {
+			continue
+		}
+		m.Headers[name] = r.Headers.Get(name)
+	}
+	if utf8.Valid(r.Body) {
+		m.Body = string(r.Body)
+	} else {
+		m.Body = base64.StdEncoding.EncodeToString(r.Body)
+		m.Base64 = true
+	}
+	return m
+}
+
+// Liest einen Body als Dokument, in dem Felder nachgeschlagen werden können.
+// Formulare werden als Objekt mit String-Werten behandelt, andere Bodies als JSON.
+func bodyDocument(params map[string]string, body []byte) interface{} {
+	if len(params) > 0 {
+		doc := make(map[string]interface{}, len(params))
+		for key, value := range params {
+			doc[key] = value
+		}
+		return doc
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil
+	}
+	return doc
+}
+
+// Folgt einem Pfad wie "order.items.0.id" durch ein JSON-Dokument
+func lookupField(doc interface{}, field string) (interface{}, bool) {
+	node := doc
+	for _, part := range strings.Split(field, ".") {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[part]
+			if !ok {
+				return nil, false
+			}
+			node = child
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, false
+			}
+			node = n[i]
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// Prüft, ob eine Anfrage auf eine Mock-Regel passt
+func (r *MockRule) matches(req Request, doc interface{}) bool {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return false
+	}
+	if r.Method != "" && !strings.EqualFold(r.Method, req.Method) {
+		return false
+	}
+	if !matchPath(r.Path, pathInBin(u.Path)) {
+		return false
+	}
+	for field, expected := range r.BodyFields {
+		actual, ok := lookupField(doc, field)
+		if !ok || !jsonEqual(actual, expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// Sucht die passende Mock-Regel für eine Anfrage.
+// Regeln mit mehr Body-Feldern sind spezifischer und haben Vorrang, sonst gewinnt die älteste.
+// Regeln eines Szenarios passen nur im geforderten Zustand und lösen dabei den Zustandswechsel aus.
+func findMockRule(req Request, body []byte) *MockRule {
+	rules := mockRulesInBin(req.Bin)
+	if len(rules) == 0 {
+		return nil
+	}
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].BodyFields) > len(rules[j].BodyFields)
+	})
+
+	doc := bodyDocument(req.BodyParams, body)
+	for _, rule := range rules {
+		if rule.matches(req, doc) && fireScenario(&rule) {
+			return &rule
+		}
+	}
+	return nil
+}
+
+// Gibt alle Mock-Regeln eines Bins aus
+func listMockRules(c *gin.Context) {
+	bin, ok := binFromQuery(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, mockRulesInBin(bin))
+}
+
+// Legt eine neue Mock-Regel an
+func createMockRule(c *gin.Context) {
+	var rule MockRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.String(http.StatusBadRequest, "Ungültiger Body")
+		return
+	}
+	if rule.Bin == "" {
+		rule.Bin = defaultBin
+	}
+	if !binExists(rule.Bin) {
+		c.String(http.StatusNotFound, "Bin nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, rule.Bin, true) {
+		return
+	}
+	if err := rule.validate(); err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, addMockRule(rule))
+}
+
+// Ersetzt eine Mock-Regel. ID, Bin und Erstellungszeit bleiben erhalten.
+func updateMockRule(c *gin.Context) {
+	id := c.Param("id")
+
+	mockRulesMu.RLock()
+	existing, ok := mockRules[id]
+	mockRulesMu.RUnlock()
+	if !ok {
+		c.String(http.StatusNotFound, "Mock-Regel nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, existing.Bin, true) {
+		return
+	}
+
+	var rule MockRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.String(http.StatusBadRequest, "Ungültiger Body")
+		return
+	}
+	if rule.Bin != "" && rule.Bin != existing.Bin {
+		c.String(http.StatusBadRequest, "Der Bin einer Mock-Regel kann nicht geändert werden")
+		return
+	}
+	rule.ID, rule.Bin, rule.CreatedAt = existing.ID, existing.Bin, existing.CreatedAt
+	if err := rule.validate(); err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	mockRulesMu.Lock()
+	mockRules[rule.ID] = rule
+	mockRulesMu.Unlock()
+	saveMockRuleToFile(rule)
+	c.JSON(http.StatusOK, rule)
+}
+
+// Löscht eine Mock-Regel anhand ihrer ID
+func deleteMockRule(c *gin.Context) {
+	id := c.Param("id")
+
+	mockRulesMu.RLock()
+	rule, ok := mockRules[id]
+	mockRulesMu.RUnlock()
+	if !ok {
+		c.String(http.StatusNotFound, "Mock-Regel nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, rule.Bin, true) {
+		return
+	}
+
+	removeMockRule(id)
+	c.Status(http.StatusNoContent)
+}
+
+// Erzeugt Mock-Regeln aus gespeicherten Anfragen samt ihrer aufgezeichneten Antworten.
+// Erwartet {"ids": [...], "body_fields": ["order.id", ...]}. Die Werte der Body-Felder werden
+// aus der jeweiligen Anfrage übernommen, Methode und Pfad passen exakt.
+func createMockRulesFromRequests(c *gin.Context) {
+	var body struct {
+		IDs        []string `json:"ids"`
+		BodyFields []string `json:"body_fields"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || len(body.IDs) == 0 {
+		c.String(http.StatusBadRequest, "Ungültiger Body")
+		return
+	}
+
+	// Zuerst alle Anfragen suchen und die Berechtigung prüfen, damit ein 403 keine halbe Menge an Regeln hinterlässt
+	var found []Request
+	skipped := map[string]string{}
+	for _, id := range body.IDs {
+		req, ok := findRequest(id)
+		if !ok {
+			skipped[id] = "Anfrage nicht gefunden"
+			continue
+		}
+		if !authorizeBin(c, req.Bin, true) {
+			return
+		}
+		found = append(found, req)
+	}
+
+	created := []MockRule{}
+	for _, req := range found {
+		id := req.ID
+		if req.Response == nil {
+			skipped[id] = "Anfrage hat keine aufgezeichnete Antwort"
+			continue
+		}
+		u, err := url.Parse(req.URL)
+		if err != nil {
+			skipped[id] = "ungültige URL"
+			continue
+		}
+
+		content, err := readRequestBody(req)
+		if err != nil {
+			log.Println("Fehler beim Lesen des Request-Body:", err)
+		}
+		doc := bodyDocument(req.BodyParams, content)
+
+		fields := make(map[string]interface{})
+		for _, field := range body.BodyFields {
+			if value, ok := lookupField(doc, field); ok {
+				fields[field] = value
+			}
+		}
+
+		created = append(created, addMockRule(MockRule{
+			Bin:             req.Bin,
+			Method:          req.Method,
+			Path:            pathInBin(u.Path),
+			BodyFields:      fields,
+			Response:        mockResponseFrom(req.Response),
+			SourceRequestID: req.ID,
+		}))
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"created": created, "skipped": skipped})
+}
+
+// Exportiert alle Mock-Regeln eines Bins als Fixture-Datei
+func exportMockRules(c *gin.Context) {
+	bin, ok := binFromQuery(c)
+	if !ok {
+		return
+	}
+
+	set := FixtureSet{Version: 1, Bin: bin, ExportedAt: time.Now(), Rules: mockRulesInBin(bin)}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="fixtures-%s.json"`, bin))
+	c.IndentedJSON(http.StatusOK, set)
+}
+
+// Importiert eine Fixture-Datei in einen Bin. Mit ?replace=true werden die bisherigen Regeln des Bins vorher gelöscht.
+func importMockRules(c *gin.Context) {
+	bin, ok := binFromQuery(c)
+	if !ok {
+		return
+	}
+	if !authorizeBin(c, bin, true) {
+		return
+	}
+
+	var set FixtureSet
+	if err := c.ShouldBindJSON(&set); err != nil {
+		c.String(http.StatusBadRequest, "Ungültige Fixture-Datei")
+		return
+	}
+	for i := range set.Rules {
+		if err := set.Rules[i].validate(); err != nil {
+			c.String(http.StatusBadRequest, fmt.Sprintf("Regel %d: %s", i, err))
+			return
+		}
+	}
+
+	if c.Query("replace") == "true" {
+		deleteMockRulesForBin(bin)
+	}
+
+	imported := make([]MockRule, 0, len(set.Rules))
+	for _, rule := range set.Rules {
+		rule.Bin = bin
+		imported = append(imported, addMockRule(rule))
+	}
+	c.JSON(http.StatusCreated, imported)
+}
@@ -0,0 +1,113 @@
+package inspector
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Legt ein Token für einen Bin an und liefert das Geheimnis
+func addTestToken(role, bin string) string {
+	secret := generateTokenSecret()
+	tokensMu.Lock()
+	tokens[hashToken(secret)] = Token{ID: uuid.New().String(), Role: role, Bin: bin, Hash: hashToken(secret), CreatedAt: time.Now()}
+	tokensMu.Unlock()
+	return secret
+}
+
+// Sendet einen JSON-Body an die Management-API und liefert den Statuscode
+func managementRequest(t *testing.T, s *Server, method, path, token string, body interface{}) int {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := http.NewRequest(method, s.ManagementURL+path, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestCreateMockRulesFromRequestsAuthorizesAllFirst(t *testing.T) {
+	s := NewServer(t)
+	s.CreateBin("fremd")
+	// Die Mock-Antworten werden mit den Anfragen aufgezeichnet
+	for _, bin := range []string{defaultBin, "fremd"} {
+		s.AddMockRule(MockRule{Bin: bin, Path: "/hook", Response: MockResponse{Status: http.StatusAccepted}})
+		resp, err := http.Post(s.URL(bin, "/hook"), "application/json", strings.NewReader(`{"a":1}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	own, other := s.Requests(defaultBin), s.Requests("fremd")
+	if len(own) != 1 || len(other) != 1 {
+		t.Fatalf("%d und %d Anfragen gespeichert, erwartet je 1", len(own), len(other))
+	}
+
+	token := addTestToken(roleReadWrite, defaultBin)
+	ids := map[string][]string{"ids": {own[0].ID, other[0].ID}}
+	if status := managementRequest(t, s, http.MethodPost, "/mocks/from-requests", token, ids); status != http.StatusForbidden {
+		t.Fatalf("Status %d, erwartet 403", status)
+	}
+	if rules := s.MockRules(defaultBin); len(rules) != 1 {
+		t.Errorf("%d Regeln nach 403, erwartet nur die vorhandene", len(rules))
+	}
+
+	ids = map[string][]string{"ids": {own[0].ID}}
+	if status := managementRequest(t, s, http.MethodPost, "/mocks/from-requests", token, ids); status != http.StatusCreated {
+		t.Fatalf("Status %d, erwartet 201", status)
+	}
+	if rules := s.MockRules(defaultBin); len(rules) != 2 {
+		t.Errorf("%d Regeln, erwartet 2", len(rules))
+	}
+}
+
+// Upstream und Replay-Ziel lassen den Server beliebige Hosts anfragen und sind Admins vorbehalten
+func TestUpstreamTargetsRequireAdmin(t *testing.T) {
+	s := NewServer(t)
+	resp, err := http.Post(s.URL(defaultBin, "/hook"), "text/plain", strings.NewReader("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	id := s.Requests(defaultBin)[0].ID
+
+	token := addTestToken(roleReadWrite, defaultBin)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+	}{
+		{"Upstream setzen", http.MethodPut, "/bins/" + defaultBin + "/upstream", map[string]string{"url": "http://169.254.169.254"}},
+		{"Replay", http.MethodPost, "/requests/" + id + "/replay", map[string]string{"target": "http://169.254.169.254"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := managementRequest(t, s, tt.method, tt.path, token, tt.body); status != http.StatusForbidden {
+				t.Errorf("Status %d, erwartet 403", status)
+			}
+		})
+	}
+
+	binsMu.RLock()
+	upstream := bins[defaultBin].Upstream
+	binsMu.RUnlock()
+	if upstream != "" {
+		t.Errorf("Upstream %q gesetzt", upstream)
+	}
+}
@@ -0,0 +1,725 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// Ergebnis des Abgleichs einer Anfrage mit dem OpenAPI-Dokument ihres Bins
+type SpecResult struct {
+	OperationID  string      `json:"operation_id,omitempty"`
+	PathTemplate string      `json:"path_template,omitempty"`
+	Method       string      `json:"method,omitempty"`
+	NotInSpec    bool        `json:"not_in_spec,omitempty"`
+	Violations   []Violation `json:"violations,omitempty"`
+}
+
+// OpenAPI-Dokumente der Bins im Mock-Modus, nach Bin-Name indiziert
+var (
+	openAPISpecs   = make(map[string]map[string]interface{})
+	openAPISpecsMu sync.RWMutex
+)
+
+// Legt das Verzeichnis ./openapi an, in dem das Dokument jedes Bins im Mock-Modus gespeichert wird
+func createOpenAPIDirectory() error {
+	return os.MkdirAll(dataPath("openapi"), 0755)
+}
+
+// Lese alle OpenAPI-Dokumente aus dem Verzeichnis ./openapi
+func restoreOpenAPISpecs() {
+	entries, err := os.ReadDir(dataPath("openapi"))
+	if err != nil {
+		log.Fatal("Fehler beim Lesen der OpenAPI-Dokumente:", err)
+	}
+
+	openAPISpecsMu.Lock()
+	defer openAPISpecsMu.Unlock()
+
+	for _, entry := range entries {
+		data, err := os.ReadFile(dataPath("openapi", entry.Name()))
+		if err != nil {
+			log.Println("Fehler beim Lesen der Datei:", err)
+			continue
+		}
+
+		var doc map[string]interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			log.Println("Fehler beim Entmarshalling des OpenAPI-Dokuments:", err)
+			continue
+		}
+		openAPISpecs[strings.TrimSuffix(entry.Name(), ".json")] = doc
+	}
+}
+
+// Liest ein OpenAPI-Dokument im JSON- oder YAML-Format.
+// YAML wird über JSON normalisiert, damit Zahlen wie bei JSON als float64 vorliegen.
+func parseOpenAPIDocument(data []byte) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		var raw interface{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("Dokument ist weder JSON noch YAML: %w", err)
+		}
+		normalized, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("Dokument kann nicht nach JSON übertragen werden: %w", err)
+		}
+		if err := json.Unmarshal(normalized, &doc); err != nil {
+			return nil, err
+		}
+	}
+
+	version, _ := doc["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("nur OpenAPI 3 wird unterstützt")
+	}
+	if _, ok := doc["paths"].(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("Dokument enthält keine paths")
+	}
+	if err := checkSchemaRecursion(doc); err != nil {
+		return nil, err
+	}
+	if err := checkMinItems(doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// Lehnt unsinnig große minItems ab, z. B. {"minItems": 1e12}. Beispielwerte werden nicht durchsucht.
+func checkMinItems(node interface{}) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if min, ok := number(n["minItems"]); ok && min > maxSpecMinItems {
+			return fmt.Errorf("minItems %v ist zu groß, erlaubt sind höchstens %d", min, maxSpecMinItems)
+		}
+		for key, child := range n {
+			if schemaDataKeywords[key] {
+				continue
+			}
+			if err := checkMinItems(child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range n {
+			if err := checkMinItems(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Löst ein Objekt auf, das eventuell nur aus einer Referenz ("$ref") besteht
+func derefObject(doc, node interface{}) map[string]interface{} {
+	for i := 0; i < 16; i++ {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		ref, isRef := m["$ref"].(string)
+		if !isRef {
+			return m
+		}
+		node, _ = resolvePointer(doc, ref)
+	}
+	return nil
+}
+
+// Liefert den Basispfad aus dem ersten Eintrag unter "servers", z. B. "/v1" für "https://api.example.com/v1"
+func openAPIBasePath(doc map[string]interface{}) string {
+	servers, _ := doc["servers"].([]interface{})
+	if len(servers) == 0 {
+		return ""
+	}
+	server, _ := servers[0].(map[string]interface{})
+	raw, _ := server["url"].(string)
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(u.Path, "/")
+}
+
+// Vergleicht einen Pfad mit einer OpenAPI-Pfadvorlage und liefert die Werte der Pfadparameter
+func matchPathTemplate(template, p string) (map[string]string, bool) {
+	templateParts := strings.Split(strings.Trim(template, "/"), "/")
+	pathParts := strings.Split(strings.Trim(p, "/"), "/")
+	if len(templateParts) != len(pathParts) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, part := range templateParts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if pathParts[i] == "" {
+				return nil, false
+			}
+			value, err := url.PathUnescape(pathParts[i])
+			if err != nil {
+				value = pathParts[i]
+			}
+			params[strings.Trim(part, "{}")] = value
+			continue
+		}
+		if part != pathParts[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// Sucht die passende Operation für Methode und Pfad.
+// Vorlagen mit weniger Parametern haben Vorrang, "/pets/mine" gewinnt also vor "/pets/{id}".
+func findSpecOperation(doc map[string]interface{}, method, p string) (template string, item, op map[string]interface{}, params map[string]string) {
+	p = strings.TrimPrefix(p, openAPIBasePath(doc))
+	paths, _ := doc["paths"].(map[string]interface{})
+
+	templates := make([]string, 0, len(paths))
+	for t := range paths {
+		templates = append(templates, t)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		ci, cj := strings.Count(templates[i], "{"), strings.Count(templates[j], "{")
+		if ci != cj {
+			return ci < cj
+		}
+		return templates[i] < templates[j]
+	})
+
+	for _, t := range templates {
+		values, ok := matchPathTemplate(t, p)
+		if !ok {
+			continue
+		}
+		pathItem := derefObject(doc, paths[t])
+		operation, ok := pathItem[strings.ToLower(method)].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		return t, pathItem, operation, values
+	}
+	return "", nil, nil, nil
+}
+
+// Gleicht eine Anfrage mit dem OpenAPI-Dokument ihres Bins ab und prüft Parameter und Body.
+// Ist der Bin nicht im Mock-Modus, wird nil zurückgegeben.
+func matchSpec(req Request, header http.Header, body []byte) *SpecResult {
+	openAPISpecsMu.RLock()
+	doc, ok := openAPISpecs[req.Bin]
+	openAPISpecsMu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return &SpecResult{NotInSpec: true}
+	}
+
+	template, item, op, pathParams := findSpecOperation(doc, req.Method, pathInBin(u.Path))
+	if op == nil {
+		return &SpecResult{NotInSpec: true}
+	}
+
+	result := &SpecResult{PathTemplate: template, Method: strings.ToUpper(req.Method)}
+	result.OperationID, _ = op["operationId"].(string)
+
+	// Parameter aus dem Path Item und der Operation, die Operation überschreibt gleichnamige
+	parameters := make(map[string]map[string]interface{})
+	for _, list := range [][]interface{}{schemaList(item["parameters"]), schemaList(op["parameters"])} {
+		for _, p := range list {
+			param := derefObject(doc, p)
+			name, _ := param["name"].(string)
+			in, _ := param["in"].(string)
+			parameters[in+":"+name] = param
+		}
+	}
+
+	keys := make([]string, 0, len(parameters))
+	for k := range parameters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	query := u.Query()
+	for _, key := range keys {
+		param := parameters[key]
+		name, _ := param["name"].(string)
+		in, _ := param["in"].(string)
+		required, _ := param["required"].(bool)
+
+		var value string
+		var present bool
+		switch in {
+		case "path":
+			value, present = pathParams[name]
+			required = true
+		case "query":
+			present = query.Has(name)
+			value = query.Get(name)
+		case "header":
+			present = header.Get(name) != ""
+			value = header.Get(name)
+		default:
+			continue
+		}
+
+		instancePath := "/" + in + "/" + escapePointer(name)
+		if !present {
+			if required {
+				result.Violations = append(result.Violations, Violation{InstancePath: instancePath, Keyword: "required", Message: fmt.Sprintf("Parameter %q fehlt", name)})
+			}
+			continue
+		}
+		if schema, ok := param["schema"]; ok {
+			for _, v := range validateSchema(doc, schema, coerceParameter(doc, schema, value)) {
+				v.InstancePath = instancePath + v.InstancePath
+				result.Violations = append(result.Violations, v)
+			}
+		}
+	}
+
+	result.Violations = append(result.Violations, validateSpecBody(doc, op, req, body)...)
+	return result
+}
+
+// Wandelt einen Parameterwert aus Pfad, Query oder Header in den Typ um, den das Schema erwartet
+func coerceParameter(doc, schema interface{}, value string) interface{} {
+	s := derefObject(doc, schema)
+	switch s["type"] {
+	case "integer", "number":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	case "array":
+		items := []interface{}{}
+		for _, part := range strings.Split(value, ",") {
+			items = append(items, coerceParameter(doc, s["items"], part))
+		}
+		return items
+	}
+	return value
+}
+
+// Prüft den Request-Body gegen das Schema der Operation für den gesendeten Content-Type
+func validateSpecBody(doc map[string]interface{}, op map[string]interface{}, req Request, body []byte) []Violation {
+	requestBody := derefObject(doc, op["requestBody"])
+	if requestBody == nil {
+		return nil
+	}
+
+	if len(body) == 0 && len(req.BodyParams) == 0 {
+		if required, _ := requestBody["required"].(bool); required {
+			return []Violation{{InstancePath: "/body", Keyword: "required", Message: "Request-Body fehlt"}}
+		}
+		return nil
+	}
+
+	content, _ := requestBody["content"].(map[string]interface{})
+	mediaType, media := selectMediaType(content, req.ContentType)
+	if media == nil {
+		return []Violation{{InstancePath: "/body", Keyword: "content", Message: fmt.Sprintf("Content-Type %q ist nicht erlaubt", req.ContentType)}}
+	}
+	schema, ok := media["schema"]
+	if !ok {
+		return nil
+	}
+
+	var instance interface{}
+	if strings.Contains(mediaType, "json") {
+		if err := json.Unmarshal(body, &instance); err != nil {
+			return []Violation{{InstancePath: "/body", Keyword: "json", Message: "Body ist kein gültiges JSON: " + err.Error()}}
+		}
+	} else if strings.HasPrefix(mediaType, "application/x-www-form-urlencoded") || strings.HasPrefix(mediaType, "multipart/form-data") {
+		properties, _ := derefObject(doc, schema)["properties"].(map[string]interface{})
+		params := make(map[string]interface{}, len(req.BodyParams))
+		for key, value := range req.BodyParams {
+			params[key] = coerceParameter(doc, properties[key], value)
+		}
+		instance = params
+	} else {
+		return nil
+	}
+
+	violations := validateSchema(doc, schema, instance)
+	for i := range violations {
+		violations[i].InstancePath = "/body" + violations[i].InstancePath
+	}
+	return violations
+}
+
+// Wählt den passenden Eintrag aus einer content-Map. Unterstützt exakte Typen, "type/*" und "*/*".
+func selectMediaType(content map[string]interface{}, contentType string) (string, map[string]interface{}) {
+	contentType = strings.TrimSpace(strings.Split(contentType, ";")[0])
+	candidates := []string{contentType}
+	if i := strings.Index(contentType, "/"); i >= 0 {
+		candidates = append(candidates, contentType[:i]+"/*")
+	}
+	candidates = append(candidates, "*/*")
+
+	for _, candidate := range candidates {
+		for mediaType, m := range content {
+			if strings.EqualFold(strings.Split(mediaType, ";")[0], candidate) {
+				media, _ := m.(map[string]interface{})
+				if media == nil {
+					media = map[string]interface{}{}
+				}
+				return mediaType, media
+			}
+		}
+	}
+	return "", nil
+}
+
+// Beantwortet eine Anfrage im Mock-Modus anhand der Operation aus dem OpenAPI-Dokument.
+// Nicht im Dokument enthaltene Anfragen erhalten 404, ungültige 400 mit den Verstößen.
+// Über den Header "Prefer: code=404, example=name" lassen sich Statuscode und Beispiel auswählen.
+func respondFromSpec(c *gin.Context, req Request) {
+	result := req.Spec
+	if result.NotInSpec {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Anfrage ist nicht im OpenAPI-Dokument beschrieben"})
+		return
+	}
+	if len(result.Violations) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Anfrage entspricht nicht dem OpenAPI-Dokument", "violations": result.Violations})
+		return
+	}
+
+	openAPISpecsMu.RLock()
+	doc := openAPISpecs[req.Bin]
+	openAPISpecsMu.RUnlock()
+
+	paths, _ := doc["paths"].(map[string]interface{})
+	op, _ := derefObject(doc, paths[result.PathTemplate])[strings.ToLower(result.Method)].(map[string]interface{})
+	responses, _ := op["responses"].(map[string]interface{})
+
+	prefer := parsePreferHeader(c.GetHeader("Prefer"))
+	code, response := selectSpecResponse(doc, responses, prefer["code"])
+	status := specStatusCode(code)
+
+	content, _ := response["content"].(map[string]interface{})
+	if len(content) == 0 {
+		c.Status(status)
+		return
+	}
+
+	mediaType, media := negotiateMediaType(content, c.GetHeader("Accept"))
+	example, found := specExample(doc, media, prefer["example"])
+	if !found {
+		budget := maxSampleValues
+		example = generateSample(doc, media["schema"], 0, &budget)
+	}
+
+	if s, ok := example.(string); ok && !strings.Contains(mediaType, "json") {
+		c.Data(status, mediaType, []byte(s))
+		return
+	}
+	data, err := json.Marshal(example)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Fehler beim Erzeugen der Antwort")
+		return
+	}
+	c.Data(status, mediaType, data)
+}
+
+// Liest die Einstellungen aus einem Header wie "Prefer: code=404, example=notFound"
+func parsePreferHeader(value string) map[string]string {
+	prefs := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		key, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok {
+			prefs[strings.ToLower(key)] = strings.Trim(v, `"`)
+		}
+	}
+	return prefs
+}
+
+// Wählt die Antwort: den gewünschten Code, sonst den kleinsten 2xx-Code, sonst "default", sonst den ersten
+func selectSpecResponse(doc map[string]interface{}, responses map[string]interface{}, preferred string) (string, map[string]interface{}) {
+	if preferred != "" {
+		if r, ok := responses[preferred]; ok {
+			return preferred, derefObject(doc, r)
+		}
+	}
+
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			return code, derefObject(doc, responses[code])
+		}
+	}
+	if r, ok := responses["default"]; ok {
+		return "default", derefObject(doc, r)
+	}
+	if len(codes) > 0 {
+		return codes[0], derefObject(doc, responses[codes[0]])
+	}
+	return "200", nil
+}
+
+// Wandelt einen Antwortschlüssel wie "201", "2XX" oder "default" in einen Statuscode um
+func specStatusCode(code string) int {
+	if n, err := strconv.Atoi(code); err == nil {
+		return n
+	}
+	if len(code) == 3 && strings.HasSuffix(strings.ToUpper(code), "XX") {
+		if n, err := strconv.Atoi(code[:1]); err == nil {
+			return n * 100
+		}
+	}
+	return http.StatusOK
+}
+
+// Wählt den Content-Type der Antwort anhand des Accept-Headers, JSON wird bevorzugt
+func negotiateMediaType(content map[string]interface{}, accept string) (string, map[string]interface{}) {
+	for _, part := range strings.Split(accept, ",") {
+		wanted := strings.TrimSpace(strings.Split(part, ";")[0])
+		if wanted == "" || wanted == "*/*" {
+			continue
+		}
+		if mediaType, media := selectMediaType(content, wanted); media != nil {
+			return mediaType, media
+		}
+	}
+
+	types := make([]string, 0, len(content))
+	for mediaType := range content {
+		types = append(types, mediaType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		ji, jj := strings.Contains(types[i], "json"), strings.Contains(types[j], "json")
+		if ji != jj {
+			return ji
+		}
+		return types[i] < types[j]
+	})
+
+	media, _ := content[types[0]].(map[string]interface{})
+	return types[0], media
+}
+
+// Liefert das Beispiel eines Media Type Objects: das gewünschte oder erste aus "examples", sonst "example"
+func specExample(doc map[string]interface{}, media map[string]interface{}, preferred string) (interface{}, bool) {
+	if examples, ok := media["examples"].(map[string]interface{}); ok && len(examples) > 0 {
+		names := make([]string, 0, len(examples))
+		for name := range examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		name := names[0]
+		if _, ok := examples[preferred]; ok {
+			name = preferred
+		}
+		if example := derefObject(doc, examples[name]); example != nil {
+			if value, ok := example["value"]; ok {
+				return value, true
+			}
+		}
+	}
+	if example, ok := media["example"]; ok {
+		return example, true
+	}
+	return nil, false
+}
+
+// Grenzen für erzeugte Beispieldaten. Arrays erhalten höchstens maxSampleItems Elemente, auch wenn minItems mehr
+// verlangt. maxSampleValues begrenzt alle Werte einer Antwort, damit verschachtelte Arrays nicht exponentiell wachsen.
+const (
+	maxSampleItems  = 10
+	maxSampleValues = 10000
+	maxSpecMinItems = 1000 // größere minItems werden beim Hochladen abgelehnt
+)
+
+// Erzeugt Beispieldaten aus einem Schema. Vorhandene example-, default- und enum-Werte haben Vorrang.
+// Ist das Budget aufgebraucht, entstehen nur noch leere Werte.
+func generateSample(doc, schema interface{}, depth int, budget *int) interface{} {
+	s := derefObject(doc, schema)
+	if s == nil || depth > 8 || *budget <= 0 {
+		return nil
+	}
+	*budget--
+
+	for _, key := range []string{"example", "default", "const"} {
+		if v, ok := s[key]; ok {
+			return v
+		}
+	}
+	if enum := schemaList(s["enum"]); len(enum) > 0 {
+		return enum[0]
+	}
+	if allOf := schemaList(s["allOf"]); len(allOf) > 0 {
+		merged := map[string]interface{}{}
+		for _, sub := range allOf {
+			if obj, ok := generateSample(doc, sub, depth+1, budget).(map[string]interface{}); ok {
+				for k, v := range obj {
+					merged[k] = v
+				}
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if list := schemaList(s[key]); len(list) > 0 {
+			return generateSample(doc, list[0], depth+1, budget)
+		}
+	}
+
+	typ, _ := s["type"].(string)
+	if list, ok := s["type"].([]interface{}); ok && len(list) > 0 {
+		typ, _ = list[0].(string)
+	}
+	if typ == "" {
+		if _, ok := s["properties"]; ok {
+			typ = "object"
+		} else if _, ok := s["items"]; ok {
+			typ = "array"
+		}
+	}
+
+	switch typ {
+	case "object":
+		obj := map[string]interface{}{}
+		properties, _ := s["properties"].(map[string]interface{})
+		for name, sub := range properties {
+			obj[name] = generateSample(doc, sub, depth+1, budget)
+		}
+		return obj
+	case "array":
+		n := 1
+		if min, ok := number(s["minItems"]); ok && min > 1 {
+			n = maxSampleItems
+			if min < maxSampleItems {
+				n = int(min)
+			}
+		}
+		items := make([]interface{}, 0, n)
+		for i := 0; i < n && *budget > 0; i++ {
+			items = append(items, generateSample(doc, s["items"], depth+1, budget))
+		}
+		return items
+	case "integer", "number":
+		if min, ok := number(s["minimum"]); ok {
+			return min
+		}
+		return 0
+	case "boolean":
+		return true
+	case "string":
+		switch s["format"] {
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "date":
+			return "2024-01-01"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-4000-8000-000000000000"
+		case "uri", "url":
+			return "https://example.com"
+		}
+		return "string"
+	}
+	return nil
+}
+
+// Aktiviert den Mock-Modus eines Bins mit dem OpenAPI-Dokument aus dem Body (JSON oder YAML)
+func updateBinOpenAPI(c *gin.Context) {
+	name := c.Param("bin")
+	if !binExists(name) {
+		c.String(http.StatusNotFound, "Bin nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, name, true) {
+		return
+	}
+
+	data, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Fehler beim Lesen des Body")
+		return
+	}
+	doc, err := parseOpenAPIDocument(data)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	normalized, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Fehler beim Speichern")
+		return
+	}
+	if err := os.WriteFile(dataPath("openapi", name+".json"), normalized, 0644); err != nil {
+		log.Println("Fehler beim Schreiben der Datei:", err)
+		c.String(http.StatusInternalServerError, "Fehler beim Speichern")
+		return
+	}
+
+	openAPISpecsMu.Lock()
+	openAPISpecs[name] = doc
+	openAPISpecsMu.Unlock()
+
+	c.Status(http.StatusNoContent)
+}
+
+// Gibt das OpenAPI-Dokument eines Bins aus
+func viewBinOpenAPI(c *gin.Context) {
+	name := c.Param("bin")
+	if !authorizeBin(c, name, false) {
+		return
+	}
+
+	openAPISpecsMu.RLock()
+	doc, ok := openAPISpecs[name]
+	openAPISpecsMu.RUnlock()
+	if !ok {
+		c.String(http.StatusNotFound, "Bin ist nicht im Mock-Modus")
+		return
+	}
+	c.JSON(http.StatusOK, doc)
+}
+
+// Beendet den Mock-Modus eines Bins
+func deleteBinOpenAPI(c *gin.Context) {
+	name := c.Param("bin")
+	if !authorizeBin(c, name, true) {
+		return
+	}
+
+	deleteOpenAPIForBin(name)
+	c.Status(http.StatusNoContent)
+}
+
+// Entfernt das OpenAPI-Dokument eines Bins aus dem Speicher und vom Datenträger
+func deleteOpenAPIForBin(bin string) {
+	openAPISpecsMu.Lock()
+	delete(openAPISpecs, bin)
+	openAPISpecsMu.Unlock()
+
+	if err := os.Remove(dataPath("openapi", bin+".json")); err != nil && !os.IsNotExist(err) {
+		log.Println("Fehler beim Löschen der Datei:", err)
+	}
+}
@@ -0,0 +1,80 @@
+package inspector
+
+import (
+	"strings"
+	"testing"
+)
+
+// Zählt alle Werte in einem erzeugten Beispiel
+func countSampleValues(v interface{}) int {
+	n := 1
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			n += countSampleValues(item)
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			n += countSampleValues(item)
+		}
+	}
+	return n
+}
+
+func TestGenerateSampleLimits(t *testing.T) {
+	nested := `{"type":"string"}`
+	for i := 0; i < 8; i++ {
+		nested = `{"type":"array","minItems":1000,"items":` + nested + `}`
+	}
+
+	tests := []struct {
+		name      string
+		schema    string
+		wantItems int // Länge des äußeren Arrays, 0 für beliebig
+		maxValues int
+	}{
+		{"minItems", `{"type":"array","minItems":3,"items":{"type":"integer"}}`, 3, 4},
+		{"minItems über der Grenze", `{"type":"array","minItems":1e12,"items":{"type":"integer"}}`, maxSampleItems, maxSampleItems + 1},
+		// Das Budget ist schon im ersten Element aufgebraucht
+		{"verschachtelte Arrays", nested, 0, maxSampleValues},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := maxSampleValues
+			sample := generateSample(nil, mustJSON(t, tt.schema), 0, &budget)
+			items, ok := sample.([]interface{})
+			if !ok || (tt.wantItems > 0 && len(items) != tt.wantItems) {
+				t.Fatalf("Beispiel mit %d Elementen, erwartet %d", len(items), tt.wantItems)
+			}
+			if n := countSampleValues(sample); n > tt.maxValues {
+				t.Errorf("%d Werte erzeugt, erwartet höchstens %d", n, tt.maxValues)
+			}
+		})
+	}
+}
+
+func TestParseOpenAPIDocumentMinItems(t *testing.T) {
+	spec := func(schema string) []byte {
+		return []byte(`{"openapi":"3.0.0","paths":{},"components":{"schemas":{"Liste":` + schema + `}}}`)
+	}
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{"erlaubt", `{"type":"array","minItems":1000}`, ""},
+		{"zu groß", `{"type":"array","minItems":1001}`, "minItems"},
+		{"im Beispielwert", `{"type":"object","example":{"minItems":1e12}}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseOpenAPIDocument(spec(tt.schema))
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unerwarteter Fehler: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Fehler %v, erwartet %q", err, tt.wantErr)
+			}
+		})
+	}
+}
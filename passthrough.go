@@ -0,0 +1,209 @@
+package inspector
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Die Antwort, die an den Absender einer Anfrage ging, z. B. vom Upstream im Pass-Through-Modus
+// oder aus einer Mock-Regel
+type RecordedResponse struct {
+	Status     int         `json:"status"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+	DurationMs int64       `json:"duration_ms,omitempty"`
+}
+
+// Header, die nur für eine einzelne Verbindung gelten und nicht weitergereicht werden
+var hopByHopHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+// Client für Anfragen an den Upstream
+var upstreamClient = &http.Client{
+	Timeout: 30 * time.Second,
+	// Weiterleitungen werden unverändert an den Absender zurückgegeben
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Liefert die Upstream-URL eines Bins oder einen leeren String, wenn der Bin nicht im Pass-Through-Modus ist
+func binUpstream(bin string) string {
+	binsMu.RLock()
+	defer binsMu.RUnlock()
+	return bins[bin].Upstream
+}
+
+// Leitet eine Anfrage mit dem unveränderten Body an den Upstream des Bins weiter
+// und gibt die Antwort des Upstreams an den Absender zurück.
+// Die Antwort wird als RecordedResponse zurückgegeben, damit sie mit der Anfrage gespeichert werden kann.
+func forwardToUpstream(c *gin.Context, upstream string, body []byte) *RecordedResponse {
+	target, err := url.Parse(upstream)
+	if err != nil {
+		c.String(http.StatusBadGateway, "Ungültige Upstream-URL")
+		return &RecordedResponse{Status: http.StatusBadGateway}
+	}
+	target.Path = strings.TrimSuffix(target.Path, "/") + pathInBin(c.Request.URL.Path)
+	target.RawQuery = c.Request.URL.RawQuery
+
+	out, err := http.NewRequestWithContext(c.Request.Context(), c.Request.Method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		c.String(http.StatusBadGateway, "Fehler beim Erstellen der Upstream-Anfrage")
+		return &RecordedResponse{Status: http.StatusBadGateway}
+	}
+	out.Header = c.Request.Header.Clone()
+	for _, h := range hopByHopHeaders {
+		out.Header.Del(h)
+	}
+	out.Header.Set("X-Forwarded-For", c.ClientIP())
+
+	start := time.Now()
+	resp, err := upstreamClient.Do(out)
+	if err != nil {
+		log.Println("Fehler bei der Upstream-Anfrage:", err)
+		c.String(http.StatusBadGateway, "Upstream nicht erreichbar")
+		return &RecordedResponse{Status: http.StatusBadGateway, DurationMs: time.Since(start).Milliseconds()}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Println("Fehler beim Lesen der Upstream-Antwort:", err)
+	}
+
+	headers := resp.Header.Clone()
+	for _, h := range hopByHopHeaders {
+		headers.Del(h)
+	}
+	headers.Del("Content-Length")
+
+	recorded := &RecordedResponse{
+		Status:     resp.StatusCode,
+		Headers:    headers,
+		Body:       respBody,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	writeRecordedResponse(c, recorded)
+	return recorded
+}
+
+// Schreibt eine gespeicherte Antwort mit Status, Headern und Body an den Absender
+func writeRecordedResponse(c *gin.Context, r *RecordedResponse) {
+	for name, values := range r.Headers {
+		for _, v := range values {
+			c.Writer.Header().Add(name, v)
+		}
+	}
+	c.Status(r.Status)
+	if _, err := c.Writer.Write(r.Body); err != nil {
+		log.Println("Fehler beim Schreiben der Antwort:", err)
+	}
+}
+
+// Setzt oder entfernt den Upstream eines Bins. Erwartet ein JSON-Objekt der Form {"url": "https://..."},
+// eine leere URL beendet den Pass-Through-Modus. Nur mit Admin-Token, da der Server danach jeden Host anfragt.
+func updateBinUpstream(c *gin.Context) {
+	name := c.Param("bin")
+	if !binExists(name) {
+		c.String(http.StatusNotFound, "Bin nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, name, true) {
+		return
+	}
+
+	var body struct {
+		URL string `json:"url"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.String(http.StatusBadRequest, "Ungültiger Body")
+		return
+	}
+	if body.URL != "" {
+		u, err := url.Parse(body.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			c.String(http.StatusBadRequest, "Ungültige Upstream-URL")
+			return
+		}
+	}
+
+	// Prüfen und Ändern unter einer Sperre, damit ein zwischenzeitlich gelöschter Bin nicht wieder entsteht
+	binsMu.Lock()
+	b, ok := bins[name]
+	if !ok {
+		binsMu.Unlock()
+		c.String(http.StatusNotFound, "Bin nicht gefunden")
+		return
+	}
+	b.Upstream = body.URL
+	bins[name] = b
+	saveBinToFile(b)
+	binsMu.Unlock()
+
+	c.JSON(http.StatusOK, b.masked())
+}
+
+// Sendet eine gespeicherte Anfrage erneut an das im Body angegebene Ziel und gibt dessen Antwort aus.
+// Wie bei den Snippets mit target ersetzt das Ziel den Host, der Bin-Präfix des Pfads entfällt.
+// Nur mit Admin-Token, da das Ziel beliebig ist.
+func replayRequest(c *gin.Context) {
+	var body struct {
+		Target string `json:"target"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.Target == "" {
+		c.String(http.StatusBadRequest, "Ziel fehlt")
+		return
+	}
+
+	req, ok := findRequest(c.Param("id"))
+	if !ok {
+		c.String(http.StatusNotFound, "Anfrage nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, req.Bin, true) {
+		return
+	}
+
+	s, err := buildSnippetRequest(req, body.Target, true)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	out, err := http.NewRequestWithContext(c.Request.Context(), s.Method, s.URL, bytes.NewReader(s.Body))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Fehler beim Erstellen der Anfrage")
+		return
+	}
+	for _, h := range s.Headers {
+		out.Header.Add(h[0], h[1])
+	}
+
+	start := time.Now()
+	resp, err := upstreamClient.Do(out)
+	if err != nil {
+		log.Println("Fehler beim erneuten Senden:", err)
+		c.String(http.StatusBadGateway, "Ziel nicht erreichbar")
+		return
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Println("Fehler beim Lesen der Antwort:", err)
+	}
+	c.JSON(http.StatusOK, RecordedResponse{
+		Status:     resp.StatusCode,
+		Headers:    resp.Header,
+		Body:       respBody,
+		DurationMs: time.Since(start).Milliseconds(),
+	})
+}
@@ -0,0 +1,378 @@
+package inspector
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Ersetzungsarten für geschwärzte Werte
+const (
+	redactModeMarker = "marker" // Wert wird durch redactionMarker ersetzt
+	redactModeHash   = "hash"   // Wert wird durch einen HMAC-SHA-256 ersetzt, gleiche Geheimnisse bleiben vergleichbar
+)
+
+const redactionMarker = "[REDACTED]"
+
+// Regeln, nach denen sensible Werte vor dem Speichern einer Anfrage entfernt werden.
+// Fields enthält Namen von Formular- und Query-Parametern oder Pfade in JSON-Bodies ("user.password", "items.*.card").
+type RedactionRules struct {
+	Headers       []string `json:"headers"`
+	Fields        []string `json:"fields"`
+	Patterns      []string `json:"patterns"`
+	Mode          string   `json:"mode"`
+	HashKey       string   `json:"hash_key,omitempty"`
+	KeepEncrypted bool     `json:"keep_encrypted"`
+
+	patterns []*regexp.Regexp
+}
+
+// Standardregeln, falls keine Datei ./redaction.json vorhanden ist
+var defaultRedactionRules = RedactionRules{
+	Headers:  []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"},
+	Fields:   []string{"password", "secret", "api_key"},
+	Patterns: []string{`\b(?:\d[ -]?){12,15}\d\b`}, // Kartennummern
+	Mode:     redactModeMarker,
+}
+
+// Die aktuell gültigen Regeln
+var (
+	redactionRules   RedactionRules
+	redactionRulesMu sync.RWMutex
+)
+
+// Lese die Regeln aus ./redaction.json oder verwende die Standardregeln
+func restoreRedactionRules() {
+	rules := defaultRedactionRules
+
+	data, err := os.ReadFile(dataPath("redaction.json"))
+	if err == nil {
+		if err := json.Unmarshal(data, &rules); err != nil {
+			log.Fatal("Fehler beim Entmarshalling der Schwärzungsregeln:", err)
+		}
+	} else if !os.IsNotExist(err) {
+		log.Fatal("Fehler beim Lesen der Schwärzungsregeln:", err)
+	}
+
+	if err := rules.compile(); err != nil {
+		log.Fatal("Ungültige Schwärzungsregeln:", err)
+	}
+
+	redactionRulesMu.Lock()
+	redactionRules = rules
+	redactionRulesMu.Unlock()
+}
+
+// Prüft die Regeln und übersetzt die regulären Ausdrücke
+func (r *RedactionRules) compile() error {
+	if r.Mode == "" {
+		r.Mode = redactModeMarker
+	}
+	if r.Mode != redactModeMarker && r.Mode != redactModeHash {
+		return fmt.Errorf("unbekannter Modus %q", r.Mode)
+	}
+	if r.Mode == redactModeHash && r.HashKey == "" {
+		return fmt.Errorf("Modus %q benötigt einen hash_key", redactModeHash)
+	}
+
+	r.patterns = nil
+	for _, p := range r.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return fmt.Errorf("ungültiger Ausdruck %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return nil
+}
+
+// Ersetzt einen sensiblen Wert je nach Modus durch den Marker oder einen gekürzten HMAC
+func (r *RedactionRules) replace(value string) string {
+	if r.Mode != redactModeHash {
+		return redactionMarker
+	}
+	mac := hmac.New(sha256.New, []byte(r.HashKey))
+	mac.Write([]byte(value))
+	return "hmac:" + hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// Wendet alle regulären Ausdrücke auf einen Text an
+func (r *RedactionRules) replacePatterns(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllStringFunc(s, r.replace)
+	}
+	return s
+}
+
+// Prüft, ob ein Formular- oder Query-Parameter geschwärzt werden soll
+func (r *RedactionRules) isField(name string) bool {
+	for _, f := range r.Fields {
+		if strings.EqualFold(f, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Schwärzt Header, Query- und Formularparameter sowie den Body einer Anfrage nach den aktuellen Regeln,
+// ebenso Header und Body einer aufgezeichneten Antwort.
+// Die übergebene Request-Struct wird nicht verändert. Zurückgegeben werden die geschwärzte Kopie,
+// der geschwärzte Body und ob überhaupt etwas ersetzt wurde.
+func redactRequest(req Request, body []byte) (Request, []byte, bool) {
+	redactionRulesMu.RLock()
+	rules := redactionRules
+	redactionRulesMu.RUnlock()
+
+	// Header
+	headers, changed := rules.redactHeaders(req.Headers)
+	req.Headers = headers
+
+	// Query-Parameter
+	if u, err := url.Parse(req.URL); err == nil && u.RawQuery != "" {
+		query := u.Query()
+		queryChanged := false
+		for key, values := range query {
+			if !rules.isField(key) {
+				continue
+			}
+			for i, v := range values {
+				values[i] = rules.replace(v)
+				queryChanged = true
+			}
+		}
+		if queryChanged {
+			u.RawQuery = query.Encode()
+			req.URL = u.String()
+			changed = true
+		}
+	}
+
+	// Formularparameter
+	params := make(map[string]string, len(req.BodyParams))
+	for key, value := range req.BodyParams {
+		if rules.isField(key) {
+			params[key] = rules.replace(value)
+		} else {
+			params[key] = rules.replacePatterns(value)
+		}
+		changed = changed || params[key] != value
+	}
+	req.BodyParams = params
+
+	// Body
+	redactedBody := rules.redactBody(req.ContentType, body)
+	changed = changed || !bytes.Equal(redactedBody, body)
+
+	// Aufgezeichnete Antwort, z. B. Set-Cookie eines Upstreams
+	if req.Response != nil {
+		resp := *req.Response
+		var headersChanged bool
+		resp.Headers, headersChanged = rules.redactHeaders(resp.Headers)
+		resp.Body = rules.redactBody(resp.Headers.Get("Content-Type"), req.Response.Body)
+		changed = changed || headersChanged || !bytes.Equal(resp.Body, req.Response.Body)
+		req.Response = &resp
+	}
+
+	return req, redactedBody, changed
+}
+
+// Ersetzt die Werte der Header aus den Regeln in einer Kopie der Header
+func (r *RedactionRules) redactHeaders(h http.Header) (http.Header, bool) {
+	headers := h.Clone()
+	changed := false
+	for _, name := range r.Headers {
+		values := headers.Values(name)
+		for i, v := range values {
+			values[i] = r.replace(v)
+			changed = true
+		}
+	}
+	return headers, changed
+}
+
+// Schwärzt einen Body: zuerst JSON-Pfade, danach reguläre Ausdrücke auf Textinhalte
+func (r *RedactionRules) redactBody(contentType string, body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	redacted := body
+	if strings.Contains(contentType, "json") {
+		redacted = redactJSONBody(r, redacted)
+	}
+	if utf8.Valid(redacted) {
+		redacted = []byte(r.replacePatterns(string(redacted)))
+	}
+	return redacted
+}
+
+// Schwärzt alle in rules.Fields angegebenen Pfade eines JSON-Bodies.
+// Kann der Body nicht gelesen werden, wird er unverändert zurückgegeben.
+func redactJSONBody(rules *RedactionRules, body []byte) []byte {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return body
+	}
+
+	changed := false
+	for _, field := range rules.Fields {
+		doc = redactJSONPath(rules, doc, strings.Split(field, "."), &changed)
+	}
+	if !changed {
+		return body
+	}
+
+	redacted, err := json.Marshal(doc)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// Folgt einem Pfad durch ein JSON-Dokument und ersetzt die gefundenen Werte.
+// "*" passt auf jeden Schlüssel bzw. jeden Array-Index.
+// Ein Pfad aus nur einem Segment passt zusätzlich auf gleichnamige Schlüssel in jeder Tiefe.
+func redactJSONPath(rules *RedactionRules, node interface{}, path []string, changed *bool) interface{} {
+	if len(path) == 0 {
+		*changed = true
+		if s, ok := node.(string); ok {
+			return rules.replace(s)
+		}
+		raw, _ := json.Marshal(node)
+		return rules.replace(string(raw))
+	}
+
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if path[0] == "*" || path[0] == key {
+				v[key] = redactJSONPath(rules, child, path[1:], changed)
+			} else if len(path) == 1 {
+				v[key] = redactJSONPath(rules, child, path, changed)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if path[0] == "*" || path[0] == strconv.Itoa(i) {
+				v[i] = redactJSONPath(rules, child, path[1:], changed)
+			} else if len(path) == 1 {
+				v[i] = redactJSONPath(rules, child, path, changed)
+			}
+		}
+	}
+	return node
+}
+
+// Eine ungeschwärzte Kopie einer Anfrage, die verschlüsselt abgelegt wird
+type unredactedCopy struct {
+	Request Request `json:"request"`
+	Body    []byte  `json:"body,omitempty"`
+}
+
+// Legt das Verzeichnis ./unredacted für die verschlüsselten Originale an
+func createUnredactedDirectory() error {
+	return os.MkdirAll(dataPath("unredacted"), 0700)
+}
+
+// Speichert das ungeschwärzte Original einer Anfrage verschlüsselt in ./unredacted/{id}.enc,
+// sofern keep_encrypted gesetzt ist. Ohne konfigurierten Schlüssel wird nichts gespeichert.
+func saveUnredactedCopy(r Request, body []byte) {
+	redactionRulesMu.RLock()
+	keep := redactionRules.KeepEncrypted
+	redactionRulesMu.RUnlock()
+	if !keep {
+		return
+	}
+	if encryptionKey == nil {
+		log.Println("Ungeschwärzte Kopie nicht gespeichert: kein Schlüssel konfiguriert")
+		return
+	}
+
+	data, err := json.Marshal(unredactedCopy{Request: r, Body: body})
+	if err != nil {
+		log.Println("Fehler beim Marshalling der ungeschwärzten Kopie:", err)
+		return
+	}
+
+	if err := writeStoredFile(dataPath("unredacted", r.ID+".enc"), data); err != nil {
+		log.Println("Fehler beim Schreiben der Datei:", err)
+	}
+}
+
+// Gibt die entschlüsselte, ungeschwärzte Kopie einer Anfrage aus (nur für Admins)
+func viewUnredactedRequest(c *gin.Context) {
+	path := dataPath("unredacted", c.Param("id")+".enc")
+	if _, err := os.Stat(path); err != nil {
+		c.String(http.StatusNotFound, "Keine ungeschwärzte Kopie vorhanden")
+		return
+	}
+
+	plain, err := readStoredFile(path)
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Fehler beim Entschlüsseln")
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", plain)
+}
+
+// Gibt die aktuellen Schwärzungsregeln aus, der HMAC-Schlüssel wird dabei maskiert
+func viewRedactionRules(c *gin.Context) {
+	redactionRulesMu.RLock()
+	rules := redactionRules
+	redactionRulesMu.RUnlock()
+
+	if rules.HashKey != "" {
+		rules.HashKey = "***"
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// Ersetzt die Schwärzungsregeln und speichert sie in ./redaction.json.
+// Wird kein neuer hash_key übergeben, bleibt der bisherige erhalten.
+func updateRedactionRules(c *gin.Context) {
+	var rules RedactionRules
+	if err := c.ShouldBindJSON(&rules); err != nil {
+		c.String(http.StatusBadRequest, "Ungültiger Body")
+		return
+	}
+
+	redactionRulesMu.Lock()
+	defer redactionRulesMu.Unlock()
+
+	if rules.HashKey == "" || rules.HashKey == "***" {
+		rules.HashKey = redactionRules.HashKey
+	}
+	if err := rules.compile(); err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	data, err := json.MarshalIndent(rules, "", "    ")
+	if err != nil {
+		log.Println("Fehler beim Marshalling der Schwärzungsregeln:", err)
+		c.String(http.StatusInternalServerError, "Fehler beim Speichern")
+		return
+	}
+	if err := os.WriteFile(dataPath("redaction.json"), data, 0600); err != nil {
+		log.Println("Fehler beim Schreiben der Datei:", err)
+		c.String(http.StatusInternalServerError, "Fehler beim Speichern")
+		return
+	}
+
+	redactionRules = rules
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,65 @@
+package inspector
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestRedactRequestResponse(t *testing.T) {
+	redactionRulesMu.Lock()
+	saved := redactionRules
+	redactionRules = defaultRedactionRules
+	redactionRules.Fields = []string{"password", "token"}
+	redactionRules.patterns = nil
+	redactionRulesMu.Unlock()
+	t.Cleanup(func() {
+		redactionRulesMu.Lock()
+		redactionRules = saved
+		redactionRulesMu.Unlock()
+	})
+
+	tests := []struct {
+		name        string
+		resp        *RecordedResponse
+		wantHeader  string // Wert von Set-Cookie nach dem Schwärzen
+		wantBody    string
+		wantChanged bool
+	}{
+		{"ohne Antwort", nil, "", "", false},
+		{"unkritische Antwort", &RecordedResponse{Status: 200, Headers: http.Header{"Content-Type": {"text/plain"}}, Body: []byte("ok")}, "", "ok", false},
+		{"Set-Cookie", &RecordedResponse{Status: 200, Headers: http.Header{"Set-Cookie": {"session=abc"}}}, redactionMarker, "", true},
+		{"JSON-Feld im Body", &RecordedResponse{Status: 201, Headers: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{"token":"geheim","id":1}`)}, "", `{"id":1,"token":"[REDACTED]"}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := Request{Headers: http.Header{}, Response: tt.resp}
+			var original RecordedResponse
+			if tt.resp != nil {
+				original = *tt.resp
+				original.Headers = tt.resp.Headers.Clone()
+			}
+
+			redacted, _, changed := redactRequest(req, nil)
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %v, erwartet %v", changed, tt.wantChanged)
+			}
+			if tt.resp == nil {
+				if redacted.Response != nil {
+					t.Errorf("Antwort %+v, erwartet keine", redacted.Response)
+				}
+				return
+			}
+			if got := redacted.Response.Headers.Get("Set-Cookie"); got != tt.wantHeader {
+				t.Errorf("Set-Cookie %q, erwartet %q", got, tt.wantHeader)
+			}
+			if got := string(redacted.Response.Body); got != tt.wantBody {
+				t.Errorf("Body %q, erwartet %q", got, tt.wantBody)
+			}
+			// Das Original bleibt für die ungeschwärzte Kopie erhalten
+			if tt.resp.Headers.Get("Set-Cookie") != original.Headers.Get("Set-Cookie") || string(tt.resp.Body) != string(original.Body) {
+				t.Errorf("Original verändert: %+v", tt.resp)
+			}
+		})
+	}
+}
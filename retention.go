@@ -0,0 +1,79 @@
+package inspector
+
+import (
+	"log"
+	"os"
+	"path"
+	"time"
+)
+
+// Entfernt die Dateien einer Anfrage: den Datensatz, den Body und die ungeschwärzte Kopie
+func removeRequestFiles(r Request) {
+	files := []string{
+		dataPath(config.RequestsDir, r.ID+".json"),
+		dataPath("unredacted", r.ID+".enc"),
+	}
+	if r.LinkToFile != "" {
+		files = append(files, dataPath(config.StaticFilesDir, path.Base(r.LinkToFile)))
+	}
+	for _, f := range files {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			log.Println("Fehler beim Löschen der Datei:", err)
+		}
+	}
+}
+
+// Entfernt Anfragen, die älter als retention_max_age sind oder über retention_max_requests ihres Bins liegen.
+// 0 bedeutet jeweils unbegrenzt. Markierte Anfragen bleiben erhalten und zählen nicht zur Höchstzahl.
+// Liefert die entfernten Anfragen.
+func enforceRetention() []Request {
+	maxRequests, maxAge := config.RetentionMaxRequests, time.Duration(config.RetentionMaxAge)
+	if maxRequests == 0 && maxAge == 0 {
+		return nil
+	}
+	cutoff := time.Now().Add(-maxAge)
+
+	requestsMu.Lock()
+	counts := make(map[string]int)
+	remaining := make([]Request, 0, len(requests))
+	var evicted []Request
+	// Die Slice ist neueste zuerst sortiert, die ältesten Anfragen eines Bins fallen also zuerst heraus
+	for _, r := range requests {
+		if r.Starred {
+			remaining = append(remaining, r)
+			continue
+		}
+		counts[r.Bin]++
+		tooMany := maxRequests > 0 && counts[r.Bin] > maxRequests
+		tooOld := maxAge > 0 && r.Timestamp.Before(cutoff)
+		if tooMany || tooOld {
+			evicted = append(evicted, r)
+			continue
+		}
+		remaining = append(remaining, r)
+	}
+	requests = remaining
+	requestsMu.Unlock()
+
+	for _, r := range evicted {
+		removeRequestFiles(r)
+	}
+	recordEvictions(evicted)
+	broadcastEvictions(evicted)
+	if len(evicted) > 0 {
+		log.Printf("Aufbewahrung: %d Anfragen entfernt", len(evicted))
+	}
+	return evicted
+}
+
+// Prüft die Altersgrenze regelmäßig, auch wenn keine neuen Anfragen eintreffen
+func startRetention() {
+	if config.RetentionMaxAge == 0 {
+		return
+	}
+	go func() {
+		for range time.Tick(time.Minute) {
+			enforceRetention()
+		}
+	}()
+}
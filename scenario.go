@@ -0,0 +1,217 @@
+package inspector
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Jedes Szenario beginnt in diesem Zustand und kehrt beim Zurücksetzen dorthin zurück
+const scenarioStarted = "Started"
+
+// Der aktuelle Zustand aller Szenarien, je Bin nach Szenario-Name indiziert.
+// Szenarien ohne Eintrag befinden sich im Zustand "Started".
+var (
+	scenarioStates   = make(map[string]map[string]string)
+	scenarioStatesMu sync.Mutex
+)
+
+// Ein Szenario mit seinem aktuellen Zustand und allen Zuständen, die in den Mock-Regeln vorkommen
+type Scenario struct {
+	Name   string   `json:"name"`
+	State  string   `json:"state"`
+	States []string `json:"states,omitempty"`
+}
+
+// Lese die Zustände der Szenarien aus ./scenarios.json, damit sie einen Neustart überdauern
+func restoreScenarioStates() {
+	data, err := os.ReadFile(dataPath("scenarios.json"))
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.Fatal("Fehler beim Lesen der Szenarien:", err)
+	}
+
+	scenarioStatesMu.Lock()
+	defer scenarioStatesMu.Unlock()
+	if err := json.Unmarshal(data, &scenarioStates); err != nil {
+		log.Fatal("Fehler beim Entmarshalling der Szenarien:", err)
+	}
+}
+
+// Speichere die Zustände aller Szenarien. Der Aufrufer muss scenarioStatesMu halten.
+func saveScenarioStates() {
+	data, err := json.MarshalIndent(scenarioStates, "", "    ")
+	if err != nil {
+		log.Println("Fehler beim Marshalling der Szenarien:", err)
+		return
+	}
+
+	if err := os.WriteFile(dataPath("scenarios.json"), data, 0644); err != nil {
+		log.Println("Fehler beim Schreiben der Datei:", err)
+	}
+}
+
+// Liefert den Zustand eines Szenarios. Der Aufrufer muss scenarioStatesMu halten.
+func scenarioState(bin, name string) string {
+	if state, ok := scenarioStates[bin][name]; ok {
+		return state
+	}
+	return scenarioStarted
+}
+
+// Setzt den Zustand eines Szenarios. Der Aufrufer muss scenarioStatesMu halten.
+func setScenarioState(bin, name, state string) {
+	if scenarioStates[bin] == nil {
+		scenarioStates[bin] = make(map[string]string)
+	}
+	if state == scenarioStarted {
+		delete(scenarioStates[bin], name)
+	} else {
+		scenarioStates[bin][name] = state
+	}
+	saveScenarioStates()
+}
+
+// Prüft, ob eine Mock-Regel im aktuellen Zustand ihres Szenarios feuern darf, und führt
+// gegebenenfalls den Zustandswechsel aus. Prüfung und Wechsel geschehen unter derselben Sperre,
+// damit gleichzeitige Anfragen nicht beide denselben Zustand sehen.
+func fireScenario(rule *MockRule) bool {
+	if rule.Scenario == "" {
+		return true
+	}
+
+	scenarioStatesMu.Lock()
+	defer scenarioStatesMu.Unlock()
+
+	if rule.RequiredState != "" && scenarioState(rule.Bin, rule.Scenario) != rule.RequiredState {
+		return false
+	}
+	if rule.NewState != "" {
+		setScenarioState(rule.Bin, rule.Scenario, rule.NewState)
+	}
+	return true
+}
+
+// Entfernt die Zustände aller Szenarien eines gelöschten Bins
+func deleteScenariosForBin(bin string) {
+	scenarioStatesMu.Lock()
+	defer scenarioStatesMu.Unlock()
+
+	if _, ok := scenarioStates[bin]; ok {
+		delete(scenarioStates, bin)
+		saveScenarioStates()
+	}
+}
+
+// Sammelt alle Szenarien eines Bins aus seinen Mock-Regeln und Zuständen
+func scenariosInBin(bin string) []Scenario {
+	states := make(map[string]map[string]bool)
+	add := func(name, state string) {
+		if states[name] == nil {
+			states[name] = map[string]bool{scenarioStarted: true}
+		}
+		if state != "" {
+			states[name][state] = true
+		}
+	}
+	for _, rule := range mockRulesInBin(bin) {
+		if rule.Scenario != "" {
+			add(rule.Scenario, rule.RequiredState)
+			add(rule.Scenario, rule.NewState)
+		}
+	}
+
+	scenarioStatesMu.Lock()
+	for name, state := range scenarioStates[bin] {
+		add(name, state)
+	}
+	list := make([]Scenario, 0, len(states))
+	for name, known := range states {
+		s := Scenario{Name: name, State: scenarioState(bin, name)}
+		for state := range known {
+			s.States = append(s.States, state)
+		}
+		sort.Strings(s.States)
+		list = append(list, s)
+	}
+	scenarioStatesMu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Gibt alle Szenarien eines Bins mit ihrem aktuellen Zustand aus
+func listScenarios(c *gin.Context) {
+	bin, ok := binFromQuery(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, scenariosInBin(bin))
+}
+
+// Setzt den Zustand eines Szenarios. Erwartet ein JSON-Objekt der Form {"state": "..."}.
+func updateScenario(c *gin.Context) {
+	bin, ok := binFromQuery(c)
+	if !ok {
+		return
+	}
+	if !authorizeBin(c, bin, true) {
+		return
+	}
+
+	var body struct {
+		State string `json:"state"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || body.State == "" {
+		c.String(http.StatusBadRequest, "Ungültiger Body")
+		return
+	}
+
+	name := c.Param("name")
+	scenarioStatesMu.Lock()
+	setScenarioState(bin, name, body.State)
+	scenarioStatesMu.Unlock()
+
+	c.JSON(http.StatusOK, Scenario{Name: name, State: body.State})
+}
+
+// Setzt ein Szenario in den Zustand "Started" zurück
+func resetScenario(c *gin.Context) {
+	bin, ok := binFromQuery(c)
+	if !ok {
+		return
+	}
+	if !authorizeBin(c, bin, true) {
+		return
+	}
+
+	name := c.Param("name")
+	scenarioStatesMu.Lock()
+	setScenarioState(bin, name, scenarioStarted)
+	scenarioStatesMu.Unlock()
+
+	c.JSON(http.StatusOK, Scenario{Name: name, State: scenarioStarted})
+}
+
+// Setzt alle Szenarien eines Bins in den Zustand "Started" zurück
+func resetAllScenarios(c *gin.Context) {
+	bin, ok := binFromQuery(c)
+	if !ok {
+		return
+	}
+	if !authorizeBin(c, bin, true) {
+		return
+	}
+
+	deleteScenariosForBin(bin)
+	c.JSON(http.StatusOK, scenariosInBin(bin))
+}
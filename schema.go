@@ -0,0 +1,367 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Eine Regel, die eingehende Anfragen eines Bins gegen ein JSON Schema prüft.
+// Statt Schema kann ein OpenAPI-Dokument mit der ID einer Operation angegeben werden,
+// dann wird das Schema des JSON-Request-Bodies dieser Operation verwendet.
+type SchemaRule struct {
+	ID          string      `json:"id"`
+	Bin         string      `json:"bin"`
+	Method      string      `json:"method,omitempty"` // leer für alle Methoden, Anfragen ohne Body werden dann nicht geprüft
+	Path        string      `json:"path"`             // Pfadmuster, z. B. "/orders/{id}" oder "/hooks/*"
+	Schema      interface{} `json:"schema,omitempty"`
+	OpenAPI     interface{} `json:"openapi,omitempty"`
+	OperationID string      `json:"operation_id,omitempty"`
+	Reject      bool        `json:"reject"` // ungültige Anfragen mit 400 und den Fehlern beantworten
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+// Das Ergebnis der Schema-Prüfung, das mit der Anfrage gespeichert wird
+type ValidationResult struct {
+	RuleID     string      `json:"rule_id"`
+	Valid      bool        `json:"valid"`
+	Violations []Violation `json:"violations,omitempty"`
+	Rejected   bool        `json:"rejected,omitempty"`
+}
+
+// Alle registrierten Schema-Regeln, nach ID indiziert
+var (
+	schemaRules   = make(map[string]SchemaRule)
+	schemaRulesMu sync.RWMutex
+)
+
+// Legt das Verzeichnis ./schemas an, in dem jede Schema-Regel als eigene Datei gespeichert wird
+func createSchemasDirectory() error {
+	return os.MkdirAll(dataPath("schemas"), 0755)
+}
+
+// Lese alle Schema-Regeln aus dem Verzeichnis ./schemas
+func restoreSchemaRules() {
+	entries, err := os.ReadDir(dataPath("schemas"))
+	if err != nil {
+		log.Fatal("Fehler beim Lesen der Schema-Regeln:", err)
+	}
+
+	schemaRulesMu.Lock()
+	defer schemaRulesMu.Unlock()
+
+	for _, entry := range entries {
+		data, err := os.ReadFile(dataPath("schemas", entry.Name()))
+		if err != nil {
+			log.Println("Fehler beim Lesen der Datei:", err)
+			continue
+		}
+
+		var rule SchemaRule
+		if err := json.Unmarshal(data, &rule); err != nil {
+			log.Println("Fehler beim Entmarshalling der Schema-Regel:", err)
+			continue
+		}
+		schemaRules[rule.ID] = rule
+	}
+}
+
+// Speichere eine Schema-Regel in die Datei ./schemas/{id}.json
+func saveSchemaRuleToFile(rule SchemaRule) {
+	data, err := json.MarshalIndent(rule, "", "    ")
+	if err != nil {
+		log.Println("Fehler beim Marshalling der Schema-Regel:", err)
+		return
+	}
+
+	if err := os.WriteFile(dataPath("schemas", rule.ID+".json"), data, 0644); err != nil {
+		log.Println("Fehler beim Schreiben der Datei:", err)
+	}
+}
+
+// Vergleicht einen Pfad mit einem Muster.
+// Im Muster passt "*" sowie "{name}" auf genau ein Segment und "**" am Ende auf beliebig viele.
+// Andere Segmente werden mit path.Match verglichen, "*.json" ist also ebenfalls möglich.
+func matchPath(pattern, p string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(p, "/"), "/")
+
+	for i, part := range patternParts {
+		if part == "**" {
+			return true
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if ok, err := path.Match(part, pathParts[i]); err != nil || !ok {
+			return false
+		}
+	}
+	return len(patternParts) == len(pathParts)
+}
+
+// Liefert das Schema und das Wurzeldokument für die Auflösung von Referenzen
+func (r *SchemaRule) resolveSchema() (root, schema interface{}, err error) {
+	if r.Schema != nil {
+		return r.Schema, r.Schema, nil
+	}
+
+	op, _, _, found := findOpenAPIOperation(r.OpenAPI, r.OperationID)
+	if !found {
+		return nil, nil, fmt.Errorf("Operation %q nicht im OpenAPI-Dokument gefunden", r.OperationID)
+	}
+	schema, found = openAPIRequestSchema(op)
+	if !found {
+		return nil, nil, fmt.Errorf("Operation %q hat kein JSON-Schema für den Request-Body", r.OperationID)
+	}
+	return r.OpenAPI, schema, nil
+}
+
+// Ob die Regel einen Body verlangt: bei OpenAPI-Operationen laut requestBody.required,
+// bei einem Schema nur, wenn die Regel ausdrücklich für eine Methode angelegt wurde
+func (r *SchemaRule) bodyRequired() bool {
+	if r.Schema != nil {
+		return r.Method != ""
+	}
+	op, _, _, found := findOpenAPIOperation(r.OpenAPI, r.OperationID)
+	if !found {
+		return false
+	}
+	required, _ := derefObject(r.OpenAPI, op["requestBody"])["required"].(bool)
+	return required
+}
+
+// Sucht eine Operation anhand ihrer operationId in einem OpenAPI-Dokument
+// und liefert sie zusammen mit Pfad und Methode
+func findOpenAPIOperation(doc interface{}, operationID string) (map[string]interface{}, string, string, bool) {
+	root, _ := doc.(map[string]interface{})
+	paths, _ := root["paths"].(map[string]interface{})
+	for p, item := range paths {
+		methods, _ := item.(map[string]interface{})
+		for method, op := range methods {
+			operation, ok := op.(map[string]interface{})
+			if ok && operation["operationId"] == operationID {
+				return operation, p, strings.ToUpper(method), true
+			}
+		}
+	}
+	return nil, "", "", false
+}
+
+// Liefert das Schema des JSON-Request-Bodies einer OpenAPI-Operation
+func openAPIRequestSchema(op map[string]interface{}) (interface{}, bool) {
+	body, _ := op["requestBody"].(map[string]interface{})
+	content, _ := body["content"].(map[string]interface{})
+	for mediaType, m := range content {
+		if !strings.Contains(mediaType, "json") {
+			continue
+		}
+		media, _ := m.(map[string]interface{})
+		if schema, ok := media["schema"]; ok {
+			return schema, true
+		}
+	}
+	return nil, false
+}
+
+// Sucht die erste passende Schema-Regel für eine Anfrage und prüft den Body dagegen.
+// JSON-Bodies werden direkt geprüft, Formulare als Objekt mit String-Werten.
+// Passt keine Regel, wird nil zurückgegeben.
+func validateRequest(req Request, body []byte) *ValidationResult {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil
+	}
+	p := pathInBin(u.Path)
+
+	schemaRulesMu.RLock()
+	var matching []SchemaRule
+	for _, rule := range schemaRules {
+		if rule.Bin == req.Bin && (rule.Method == "" || strings.EqualFold(rule.Method, req.Method)) && matchPath(rule.Path, p) {
+			matching = append(matching, rule)
+		}
+	}
+	schemaRulesMu.RUnlock()
+	if len(matching) == 0 {
+		return nil
+	}
+
+	// Bei mehreren passenden Regeln gewinnt die älteste
+	sort.Slice(matching, func(i, j int) bool {
+		return matching[i].CreatedAt.Before(matching[j].CreatedAt)
+	})
+	rule := matching[0]
+
+	result := &ValidationResult{RuleID: rule.ID}
+
+	// Anfragen ohne Body, z. B. GET und HEAD, werden nur beanstandet, wenn die Regel einen Body verlangt
+	if len(body) == 0 && len(req.BodyParams) == 0 {
+		if !rule.bodyRequired() {
+			return nil
+		}
+		result.Violations = []Violation{{InstancePath: "/body", Keyword: "required", Message: "Request-Body fehlt"}}
+		result.Rejected = rule.Reject
+		return result
+	}
+
+	root, schema, err := rule.resolveSchema()
+	if err != nil {
+		result.Violations = []Violation{{Keyword: "schema", Message: err.Error()}}
+		return result
+	}
+
+	var instance interface{}
+	if len(req.BodyParams) > 0 {
+		params := make(map[string]interface{}, len(req.BodyParams))
+		for key, value := range req.BodyParams {
+			params[key] = value
+		}
+		instance = params
+	} else if err := json.Unmarshal(body, &instance); err != nil {
+		result.Violations = []Violation{{Keyword: "json", Message: "Body ist kein gültiges JSON: " + err.Error()}}
+		result.Rejected = rule.Reject
+		return result
+	}
+
+	result.Violations = validateSchema(root, schema, instance)
+	result.Valid = len(result.Violations) == 0
+	result.Rejected = rule.Reject && !result.Valid
+	return result
+}
+
+// Entfernt alle Schema-Regeln eines gelöschten Bins
+func deleteSchemaRulesForBin(bin string) {
+	schemaRulesMu.Lock()
+	defer schemaRulesMu.Unlock()
+
+	for id, rule := range schemaRules {
+		if rule.Bin != bin {
+			continue
+		}
+		delete(schemaRules, id)
+		if err := os.Remove(dataPath("schemas", id+".json")); err != nil {
+			log.Println("Fehler beim Löschen der Datei:", err)
+		}
+	}
+}
+
+// Gibt alle Schema-Regeln eines Bins aus
+func listSchemaRules(c *gin.Context) {
+	bin, ok := binFromQuery(c)
+	if !ok {
+		return
+	}
+
+	schemaRulesMu.RLock()
+	list := []SchemaRule{}
+	for _, rule := range schemaRules {
+		if rule.Bin == bin {
+			list = append(list, rule)
+		}
+	}
+	schemaRulesMu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	c.JSON(http.StatusOK, list)
+}
+
+// Registriert eine neue Schema-Regel. Fehlen Pfad oder Methode bei einer OpenAPI-Operation,
+// werden sie aus dem Dokument übernommen.
+func createSchemaRule(c *gin.Context) {
+	var rule SchemaRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		c.String(http.StatusBadRequest, "Ungültiger Body")
+		return
+	}
+	if rule.Bin == "" {
+		rule.Bin = defaultBin
+	}
+	if !binExists(rule.Bin) {
+		c.String(http.StatusNotFound, "Bin nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, rule.Bin, true) {
+		return
+	}
+
+	if rule.Schema == nil && rule.OpenAPI != nil {
+		_, p, method, found := findOpenAPIOperation(rule.OpenAPI, rule.OperationID)
+		if !found {
+			c.String(http.StatusBadRequest, fmt.Sprintf("Operation %q nicht im OpenAPI-Dokument gefunden", rule.OperationID))
+			return
+		}
+		if rule.Path == "" {
+			rule.Path = p
+		}
+		if rule.Method == "" {
+			rule.Method = method
+		}
+	}
+	if rule.Path == "" || (rule.Schema == nil && rule.OpenAPI == nil) {
+		c.String(http.StatusBadRequest, "Pfad und Schema bzw. OpenAPI-Dokument sind erforderlich")
+		return
+	}
+	root, _, err := rule.resolveSchema()
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := checkSchemaRecursion(root); err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rule.ID = uuid.New().String()
+	rule.CreatedAt = time.Now()
+
+	schemaRulesMu.Lock()
+	schemaRules[rule.ID] = rule
+	schemaRulesMu.Unlock()
+	saveSchemaRuleToFile(rule)
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// Löscht eine Schema-Regel anhand ihrer ID
+func deleteSchemaRule(c *gin.Context) {
+	id := c.Param("id")
+
+	schemaRulesMu.RLock()
+	rule, ok := schemaRules[id]
+	schemaRulesMu.RUnlock()
+	if !ok {
+		c.String(http.StatusNotFound, "Schema-Regel nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, rule.Bin, true) {
+		return
+	}
+
+	schemaRulesMu.Lock()
+	delete(schemaRules, id)
+	schemaRulesMu.Unlock()
+
+	if err := os.Remove(dataPath("schemas", id+".json")); err != nil {
+		log.Println("Fehler beim Löschen der Datei:", err)
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,61 @@
+package inspector
+
+import (
+	"testing"
+)
+
+func TestValidateRequestWithoutBody(t *testing.T) {
+	openAPI := func(required bool) interface{} {
+		return map[string]interface{}{
+			"openapi": "3.0.3",
+			"paths": map[string]interface{}{"/orders": map[string]interface{}{"post": map[string]interface{}{
+				"operationId": "createOrder",
+				"requestBody": map[string]interface{}{
+					"required": required,
+					"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]interface{}{"type": "object"}}},
+				},
+			}}},
+		}
+	}
+	schema := map[string]interface{}{"type": "object"}
+
+	tests := []struct {
+		name         string
+		rule         SchemaRule
+		method       string
+		body         string
+		wantResult   bool
+		wantRejected bool
+	}{
+		{"ohne Methode, GET ohne Body", SchemaRule{Schema: schema, Reject: true}, "GET", "", false, false},
+		{"ohne Methode, POST mit Body", SchemaRule{Schema: schema, Reject: true}, "POST", `{}`, true, false},
+		{"ohne Methode, ungültiger Body", SchemaRule{Schema: schema, Reject: true}, "POST", `[]`, true, true},
+		{"mit Methode, POST ohne Body", SchemaRule{Schema: schema, Method: "POST", Reject: true}, "POST", "", true, true},
+		{"OpenAPI, Body optional", SchemaRule{OpenAPI: openAPI(false), OperationID: "createOrder", Method: "POST", Reject: true}, "POST", "", false, false},
+		{"OpenAPI, Body erforderlich", SchemaRule{OpenAPI: openAPI(true), OperationID: "createOrder", Method: "POST", Reject: true}, "POST", "", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := tt.rule
+			rule.ID, rule.Bin, rule.Path = "rule", defaultBin, "/orders"
+			schemaRulesMu.Lock()
+			schemaRules = map[string]SchemaRule{rule.ID: rule}
+			schemaRulesMu.Unlock()
+			t.Cleanup(func() {
+				schemaRulesMu.Lock()
+				schemaRules = make(map[string]SchemaRule)
+				schemaRulesMu.Unlock()
+			})
+
+			req := Request{Bin: defaultBin, Method: tt.method, URL: "http://localhost:8080/orders"}
+			result := validateRequest(req, []byte(tt.body))
+			if (result != nil) != tt.wantResult {
+				t.Fatalf("Ergebnis %+v, erwartet ein Ergebnis: %v", result, tt.wantResult)
+			}
+			if result != nil && result.Rejected != tt.wantRejected {
+				t.Errorf("Rejected = %v, erwartet %v (%+v)", result.Rejected, tt.wantRejected, result.Violations)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package inspector
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Unterstützte Anbieter für die Prüfung von Webhook-Signaturen
+const (
+	providerGitHub  = "github"
+	providerStripe  = "stripe"
+	providerSlack   = "slack"
+	providerShopify = "shopify"
+	providerHMAC    = "hmac"
+)
+
+// Ergebnis einer Signaturprüfung
+const (
+	signatureValid   = "valid"
+	signatureInvalid = "invalid"
+	signatureMissing = "missing"
+	signatureExpired = "expired"
+)
+
+// Standard-Toleranz für Zeitstempel in signierten Anfragen (Stripe, Slack)
+const defaultSignatureTolerance = 5 * time.Minute
+
+// Konfiguration der Signaturprüfung eines Bins.
+// Header, Algorithm, Encoding und Prefix werden nur vom generischen Anbieter "hmac" verwendet.
+type SignatureConfig struct {
+	Provider         string `json:"provider"`
+	Secret           string `json:"secret,omitempty"`
+	Header           string `json:"header,omitempty"`
+	Algorithm        string `json:"algorithm,omitempty"` // sha1, sha256 (Standard) oder sha512
+	Encoding         string `json:"encoding,omitempty"`  // hex (Standard) oder base64
+	Prefix           string `json:"prefix,omitempty"`    // z. B. "sha256="
+	ToleranceSeconds int    `json:"tolerance_seconds,omitempty"`
+}
+
+// Das Ergebnis der Signaturprüfung, das mit der Anfrage gespeichert wird
+type SignatureVerdict struct {
+	Provider string `json:"provider"`
+	Status   string `json:"status"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Prüft die Konfiguration auf einen bekannten Anbieter und gültige Optionen
+func (s *SignatureConfig) validate() error {
+	switch s.Provider {
+	case providerGitHub, providerStripe, providerSlack, providerShopify:
+	case providerHMAC:
+		if s.Header == "" {
+			return fmt.Errorf("Anbieter %q benötigt einen Header", providerHMAC)
+		}
+		if _, err := hashByName(s.Algorithm); err != nil {
+			return err
+		}
+		if s.Encoding != "" && s.Encoding != "hex" && s.Encoding != "base64" {
+			return fmt.Errorf("unbekannte Kodierung %q", s.Encoding)
+		}
+	default:
+		return fmt.Errorf("unbekannter Anbieter %q", s.Provider)
+	}
+	if s.Secret == "" {
+		return fmt.Errorf("Secret fehlt")
+	}
+	return nil
+}
+
+func (s *SignatureConfig) tolerance() time.Duration {
+	if s.ToleranceSeconds > 0 {
+		return time.Duration(s.ToleranceSeconds) * time.Second
+	}
+	return defaultSignatureTolerance
+}
+
+func hashByName(name string) (func() hash.Hash, error) {
+	switch name {
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha512":
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("unbekannter Algorithmus %q", name)
+}
+
+func computeHMAC(h func() hash.Hash, secret string, parts ...[]byte) []byte {
+	mac := hmac.New(h, []byte(secret))
+	for _, p := range parts {
+		mac.Write(p)
+	}
+	return mac.Sum(nil)
+}
+
+// Prüft die Signatur einer Anfrage nach der Konfiguration ihres Bins.
+// Ist für den Bin keine Prüfung konfiguriert, wird nil zurückgegeben.
+func verifySignature(bin string, header http.Header, body []byte, now time.Time) *SignatureVerdict {
+	binsMu.RLock()
+	cfg := bins[bin].Signature
+	binsMu.RUnlock()
+	if cfg == nil {
+		return nil
+	}
+
+	verdict := &SignatureVerdict{Provider: cfg.Provider}
+	verdict.Status, verdict.Reason = checkSignature(cfg, header, body, now)
+	return verdict
+}
+
+// Berechnet die erwartete Signatur und vergleicht sie mit der gesendeten.
+// Der zweite Rückgabewert erklärt, warum ein echter Empfänger die Anfrage ablehnen würde.
+func checkSignature(cfg *SignatureConfig, header http.Header, body []byte, now time.Time) (string, string) {
+	switch cfg.Provider {
+	case providerGitHub:
+		sent := header.Get("X-Hub-Signature-256")
+		if sent == "" {
+			return signatureMissing, "Header X-Hub-Signature-256 fehlt"
+		}
+		expected := "sha256=" + hex.EncodeToString(computeHMAC(sha256.New, cfg.Secret, body))
+		return compareSignature(sent, expected)
+
+	case providerShopify:
+		sent := header.Get("X-Shopify-Hmac-Sha256")
+		if sent == "" {
+			return signatureMissing, "Header X-Shopify-Hmac-Sha256 fehlt"
+		}
+		expected := base64.StdEncoding.EncodeToString(computeHMAC(sha256.New, cfg.Secret, body))
+		return compareSignature(sent, expected)
+
+	case providerSlack:
+		sent := header.Get("X-Slack-Signature")
+		timestamp := header.Get("X-Slack-Request-Timestamp")
+		if sent == "" || timestamp == "" {
+			return signatureMissing, "Header X-Slack-Signature oder X-Slack-Request-Timestamp fehlt"
+		}
+		if status, reason := checkTimestamp(cfg, timestamp, now); status != "" {
+			return status, reason
+		}
+		expected := "v0=" + hex.EncodeToString(computeHMAC(sha256.New, cfg.Secret, []byte("v0:"+timestamp+":"), body))
+		return compareSignature(sent, expected)
+
+	case providerStripe:
+		sent := header.Get("Stripe-Signature")
+		if sent == "" {
+			return signatureMissing, "Header Stripe-Signature fehlt"
+		}
+		// Format: t=1492774577,v1=5257a869...,v1=...
+		var timestamp string
+		var signatures []string
+		for _, part := range strings.Split(sent, ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch key {
+			case "t":
+				timestamp = value
+			case "v1":
+				signatures = append(signatures, value)
+			}
+		}
+		if timestamp == "" || len(signatures) == 0 {
+			return signatureMissing, "Stripe-Signature enthält keinen Zeitstempel oder keine v1-Signatur"
+		}
+		if status, reason := checkTimestamp(cfg, timestamp, now); status != "" {
+			return status, reason
+		}
+		expected := hex.EncodeToString(computeHMAC(sha256.New, cfg.Secret, []byte(timestamp+"."), body))
+		for _, s := range signatures {
+			if hmac.Equal([]byte(s), []byte(expected)) {
+				return signatureValid, ""
+			}
+		}
+		return signatureInvalid, "keine v1-Signatur stimmt mit der erwarteten überein"
+
+	case providerHMAC:
+		sent := header.Get(cfg.Header)
+		if sent == "" {
+			return signatureMissing, fmt.Sprintf("Header %s fehlt", cfg.Header)
+		}
+		h, _ := hashByName(cfg.Algorithm)
+		sum := computeHMAC(h, cfg.Secret, body)
+		expected := hex.EncodeToString(sum)
+		if cfg.Encoding == "base64" {
+			expected = base64.StdEncoding.EncodeToString(sum)
+		}
+		return compareSignature(sent, cfg.Prefix+expected)
+	}
+
+	return signatureInvalid, fmt.Sprintf("unbekannter Anbieter %q", cfg.Provider)
+}
+
+// Vergleicht zwei Signaturen in konstanter Zeit
+func compareSignature(sent, expected string) (string, string) {
+	if hmac.Equal([]byte(sent), []byte(expected)) {
+		return signatureValid, ""
+	}
+	return signatureInvalid, "Signatur stimmt nicht mit dem Body und dem Secret überein"
+}
+
+// Prüft einen Unix-Zeitstempel gegen die Toleranz. Liefert einen leeren Status, wenn er gültig ist.
+func checkTimestamp(cfg *SignatureConfig, timestamp string, now time.Time) (string, string) {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return signatureInvalid, fmt.Sprintf("ungültiger Zeitstempel %q", timestamp)
+	}
+
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > cfg.tolerance() || age < -cfg.tolerance() {
+		return signatureExpired, fmt.Sprintf("Zeitstempel weicht um %s ab, erlaubt sind %s", age.Round(time.Second), cfg.tolerance())
+	}
+	return "", ""
+}
+
+// Setzt oder entfernt die Signaturprüfung eines Bins.
+// Erwartet eine SignatureConfig als JSON, ein leerer Body bzw. "null" schaltet die Prüfung ab.
+func updateBinSignature(c *gin.Context) {
+	name := c.Param("bin")
+	if !binExists(name) {
+		c.String(http.StatusNotFound, "Bin nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, name, true) {
+		return
+	}
+
+	// Nicht über ShouldBindJSON: dessen Validierung bricht bei "null" mit einem nil-Zeiger ab
+	var cfg *SignatureConfig
+	if err := json.NewDecoder(c.Request.Body).Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		c.String(http.StatusBadRequest, "Ungültiger Body")
+		return
+	}
+	if cfg != nil {
+		if err := cfg.validate(); err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	// Der Bin kann seit der Prüfung oben gelöscht worden sein
+	binsMu.Lock()
+	b, ok := bins[name]
+	if !ok {
+		binsMu.Unlock()
+		c.String(http.StatusNotFound, "Bin nicht gefunden")
+		return
+	}
+	b.Signature = cfg
+	bins[name] = b
+	saveBinToFile(b)
+	binsMu.Unlock()
+
+	c.JSON(http.StatusOK, b.masked())
+}
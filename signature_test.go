@@ -0,0 +1,148 @@
+package inspector
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sign(h func() hash.Hash, secret, data string) []byte {
+	mac := hmac.New(h, []byte(secret))
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func TestCheckSignature(t *testing.T) {
+	const secret = "It's a Secret to Everybody"
+	const body = "Hello, World!"
+	now := time.Unix(1700000000, 0)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	old := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+
+	headers := func(kv ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i+1 < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+	slack := func(timestamp string) string {
+		return "v0=" + hex.EncodeToString(sign(sha256.New, secret, "v0:"+timestamp+":"+body))
+	}
+	stripe := func(timestamp string) string {
+		return hex.EncodeToString(sign(sha256.New, secret, timestamp+"."+body))
+	}
+
+	tests := []struct {
+		name   string
+		cfg    SignatureConfig
+		header http.Header
+		want   string
+	}{
+		// Testvektor aus der GitHub-Dokumentation
+		{"GitHub", SignatureConfig{Provider: providerGitHub}, headers("X-Hub-Signature-256", "sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17"), signatureValid},
+		{"GitHub falsch", SignatureConfig{Provider: providerGitHub}, headers("X-Hub-Signature-256", "sha256=00"), signatureInvalid},
+		{"GitHub fehlt", SignatureConfig{Provider: providerGitHub}, headers(), signatureMissing},
+		{"Shopify", SignatureConfig{Provider: providerShopify}, headers("X-Shopify-Hmac-Sha256", base64.StdEncoding.EncodeToString(sign(sha256.New, secret, body))), signatureValid},
+		{"Slack", SignatureConfig{Provider: providerSlack}, headers("X-Slack-Signature", slack(ts), "X-Slack-Request-Timestamp", ts), signatureValid},
+		{"Slack abgelaufen", SignatureConfig{Provider: providerSlack}, headers("X-Slack-Signature", slack(old), "X-Slack-Request-Timestamp", old), signatureExpired},
+		{"Slack mit Toleranz", SignatureConfig{Provider: providerSlack, ToleranceSeconds: 3600}, headers("X-Slack-Signature", slack(old), "X-Slack-Request-Timestamp", old), signatureValid},
+		{"Slack ohne Zeitstempel", SignatureConfig{Provider: providerSlack}, headers("X-Slack-Signature", slack(ts)), signatureMissing},
+		{"Stripe", SignatureConfig{Provider: providerStripe}, headers("Stripe-Signature", "t="+ts+",v1="+stripe(ts)), signatureValid},
+		{"Stripe zweite v1", SignatureConfig{Provider: providerStripe}, headers("Stripe-Signature", "t="+ts+",v1=00,v1="+stripe(ts)), signatureValid},
+		{"Stripe falsch", SignatureConfig{Provider: providerStripe}, headers("Stripe-Signature", "t="+ts+",v1=00"), signatureInvalid},
+		{"Stripe ungültiger Zeitstempel", SignatureConfig{Provider: providerStripe}, headers("Stripe-Signature", "t=gestern,v1="+stripe(ts)), signatureInvalid},
+		{"Stripe ohne v1", SignatureConfig{Provider: providerStripe}, headers("Stripe-Signature", "t="+ts), signatureMissing},
+		{"HMAC sha1 base64 mit Präfix", SignatureConfig{Provider: providerHMAC, Header: "X-Sig", Algorithm: "sha1", Encoding: "base64", Prefix: "sha1="},
+			headers("X-Sig", "sha1="+base64.StdEncoding.EncodeToString(sign(sha1.New, secret, body))), signatureValid},
+		{"HMAC ohne Präfix", SignatureConfig{Provider: providerHMAC, Header: "X-Sig", Prefix: "sha256="},
+			headers("X-Sig", hex.EncodeToString(sign(sha256.New, secret, body))), signatureInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Secret = secret
+			if err := tt.cfg.validate(); err != nil {
+				t.Fatal(err)
+			}
+			status, reason := checkSignature(&tt.cfg, tt.header, []byte(body), now)
+			if status != tt.want {
+				t.Errorf("Status %s (%s), erwartet %s", status, reason, tt.want)
+			}
+			if (status == signatureValid) != (reason == "") {
+				t.Errorf("Begründung %q zu Status %s", reason, status)
+			}
+		})
+	}
+}
+
+func TestSignatureConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SignatureConfig
+		wantErr bool
+	}{
+		{"GitHub", SignatureConfig{Provider: providerGitHub, Secret: "s"}, false},
+		{"ohne Secret", SignatureConfig{Provider: providerGitHub}, true},
+		{"unbekannter Anbieter", SignatureConfig{Provider: "gitlab", Secret: "s"}, true},
+		{"HMAC ohne Header", SignatureConfig{Provider: providerHMAC, Secret: "s"}, true},
+		{"HMAC md5", SignatureConfig{Provider: providerHMAC, Secret: "s", Header: "X-Sig", Algorithm: "md5"}, true},
+		{"HMAC base32", SignatureConfig{Provider: providerHMAC, Secret: "s", Header: "X-Sig", Encoding: "base32"}, true},
+		{"HMAC sha512", SignatureConfig{Provider: providerHMAC, Secret: "s", Header: "X-Sig", Algorithm: "sha512"}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Fehler %v, erwartet Fehler: %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// Ein leerer Body und "null" schalten die Prüfung ab
+func TestUpdateBinSignatureBody(t *testing.T) {
+	s := NewServer(t)
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantActive bool
+	}{
+		{"einschalten", `{"provider":"github","secret":"s"}`, http.StatusOK, true},
+		{"leerer Body", "", http.StatusOK, false},
+		{"wieder einschalten", `{"provider":"github","secret":"s"}`, http.StatusOK, true},
+		{"null", "null", http.StatusOK, false},
+		{"unbekannter Anbieter", `{"provider":"gitlab","secret":"s"}`, http.StatusBadRequest, false},
+		{"kein JSON", `{`, http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPut, s.ManagementURL+"/bins/"+defaultBin+"/signature", strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer "+s.AdminToken)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("Status %d, erwartet %d", resp.StatusCode, tt.wantStatus)
+			}
+
+			binsMu.RLock()
+			active := bins[defaultBin].Signature != nil
+			binsMu.RUnlock()
+			if active != tt.wantActive {
+				t.Errorf("Prüfung aktiv: %v, erwartet %v", active, tt.wantActive)
+			}
+		})
+	}
+}
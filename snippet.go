@@ -0,0 +1,286 @@
+package inspector
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Header, die der jeweilige Client selbst setzt und die deshalb nicht im Snippet stehen
+var snippetSkippedHeaders = map[string]bool{
+	"Content-Length":    true,
+	"Host":              true,
+	"Connection":        true,
+	"Accept-Encoding":   true,
+	"Transfer-Encoding": true,
+}
+
+// Die Bestandteile einer gespeicherten Anfrage, aus denen die Snippets erzeugt werden.
+// Ist BodyURL gesetzt, lädt das Snippet den Body von dort, statt ihn einzubetten.
+type snippetRequest struct {
+	Method  string
+	URL     string
+	Headers [][2]string
+	Body    []byte
+	BodyURL string
+}
+
+// Liefert den Body als Text oder, bei binären Daten, Base64-kodiert
+func (s snippetRequest) bodyText() (string, bool) {
+	if utf8.Valid(s.Body) {
+		return string(s.Body), false
+	}
+	return base64.StdEncoding.EncodeToString(s.Body), true
+}
+
+// Ein Generator erzeugt aus einer Anfrage ausführbaren Code
+type snippetGenerator func(s snippetRequest) string
+
+var snippetGenerators = map[string]snippetGenerator{
+	"curl":   curlSnippet,
+	"httpie": httpieSnippet,
+	"go":     goSnippet,
+	"python": pythonSnippet,
+	"fetch":  fetchSnippet,
+}
+
+// Setzt einen Wert für die Shell in einfache Anführungszeichen
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Setzt einen Wert als String-Literal für Python und JavaScript, deren Syntax hier der von JSON entspricht
+func jsonQuote(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
+
+func curlSnippet(s snippetRequest) string {
+	var b strings.Builder
+	body, binary := s.bodyText()
+	switch {
+	case s.BodyURL != "":
+		fmt.Fprintf(&b, "curl -s %s | ", shellQuote(s.BodyURL))
+	case binary:
+		fmt.Fprintf(&b, "echo %s | base64 -d | ", shellQuote(body))
+	}
+
+	fmt.Fprintf(&b, "curl -X %s %s", shellQuote(s.Method), shellQuote(s.URL))
+	for _, h := range s.Headers {
+		fmt.Fprintf(&b, " \\\n  -H %s", shellQuote(h[0]+": "+h[1]))
+	}
+	if s.BodyURL != "" || binary {
+		b.WriteString(" \\\n  --data-binary @-")
+	} else if len(s.Body) > 0 {
+		fmt.Fprintf(&b, " \\\n  --data-binary %s", shellQuote(body))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func httpieSnippet(s snippetRequest) string {
+	var b strings.Builder
+	body, binary := s.bodyText()
+	switch {
+	case s.BodyURL != "":
+		fmt.Fprintf(&b, "curl -s %s | ", shellQuote(s.BodyURL))
+	case binary:
+		fmt.Fprintf(&b, "echo %s | base64 -d | ", shellQuote(body))
+	case len(s.Body) > 0:
+		fmt.Fprintf(&b, "printf '%%s' %s | ", shellQuote(body))
+	}
+
+	fmt.Fprintf(&b, "http %s %s", shellQuote(s.Method), shellQuote(s.URL))
+	for _, h := range s.Headers {
+		fmt.Fprintf(&b, " \\\n  %s", shellQuote(h[0]+":"+h[1]))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+func goSnippet(s snippetRequest) string {
+	var b strings.Builder
+	body, binary := s.bodyText()
+	imports := []string{"fmt", "io", "log", "net/http"}
+	switch {
+	case s.BodyURL == "" && binary:
+		imports = append(imports, "bytes", "encoding/base64")
+	case s.BodyURL == "" && len(s.Body) > 0:
+		imports = append(imports, "strings")
+	}
+	sort.Strings(imports)
+
+	b.WriteString("package main\n\nimport (\n")
+	for _, imp := range imports {
+		fmt.Fprintf(&b, "\t%q\n", imp)
+	}
+	b.WriteString(")\n\nfunc main() {\n")
+
+	bodyExpr := "nil"
+	switch {
+	case s.BodyURL != "":
+		fmt.Fprintf(&b, "\tsrc, err := http.Get(%s)\n\tif err != nil {\n\t\tlog.Fatal(err)\n\t}\n\tdefer src.Body.Close()\n\n", strconv.Quote(s.BodyURL))
+		bodyExpr = "src.Body"
+	case binary:
+		fmt.Fprintf(&b, "\tdata, err := base64.StdEncoding.DecodeString(%s)\n\tif err != nil {\n\t\tlog.Fatal(err)\n\t}\n\n", strconv.Quote(body))
+		bodyExpr = "bytes.NewReader(data)"
+	case len(s.Body) > 0:
+		bodyExpr = fmt.Sprintf("strings.NewReader(%s)", strconv.Quote(body))
+	}
+
+	fmt.Fprintf(&b, "\treq, err := http.NewRequest(%s, %s, %s)\n", strconv.Quote(s.Method), strconv.Quote(s.URL), bodyExpr)
+	b.WriteString("\tif err != nil {\n\t\tlog.Fatal(err)\n\t}\n")
+	for _, h := range s.Headers {
+		fmt.Fprintf(&b, "\treq.Header.Add(%s, %s)\n", strconv.Quote(h[0]), strconv.Quote(h[1]))
+	}
+	b.WriteString("\n\tresp, err := http.DefaultClient.Do(req)\n\tif err != nil {\n\t\tlog.Fatal(err)\n\t}\n\tdefer resp.Body.Close()\n\n")
+	b.WriteString("\trespBody, _ := io.ReadAll(resp.Body)\n\tfmt.Println(resp.Status)\n\tfmt.Println(string(respBody))\n}\n")
+	return b.String()
+}
+
+func pythonSnippet(s snippetRequest) string {
+	var b strings.Builder
+	body, binary := s.bodyText()
+	if s.BodyURL == "" && binary {
+		b.WriteString("import base64\n")
+	}
+	b.WriteString("import requests\n\n")
+
+	b.WriteString("headers = {\n")
+	for _, h := range s.Headers {
+		fmt.Fprintf(&b, "    %s: %s,\n", jsonQuote(h[0]), jsonQuote(h[1]))
+	}
+	b.WriteString("}\n")
+
+	bodyArg := ""
+	switch {
+	case s.BodyURL != "":
+		fmt.Fprintf(&b, "data = requests.get(%s).content\n", jsonQuote(s.BodyURL))
+		bodyArg = ", data=data"
+	case binary:
+		fmt.Fprintf(&b, "data = base64.b64decode(%s)\n", jsonQuote(body))
+		bodyArg = ", data=data"
+	case len(s.Body) > 0:
+		fmt.Fprintf(&b, "data = %s.encode()\n", jsonQuote(body))
+		bodyArg = ", data=data"
+	}
+
+	fmt.Fprintf(&b, "\nresponse = requests.request(%s, %s, headers=headers%s)\n", jsonQuote(s.Method), jsonQuote(s.URL), bodyArg)
+	b.WriteString("print(response.status_code)\nprint(response.text)\n")
+	return b.String()
+}
+
+func fetchSnippet(s snippetRequest) string {
+	var b strings.Builder
+	body, binary := s.bodyText()
+
+	bodyField := ""
+	switch {
+	case s.BodyURL != "":
+		fmt.Fprintf(&b, "const body = await (await fetch(%s)).arrayBuffer();\n\n", jsonQuote(s.BodyURL))
+		bodyField = "  body,\n"
+	case binary:
+		fmt.Fprintf(&b, "const body = Uint8Array.from(atob(%s), (c) => c.charCodeAt(0));\n\n", jsonQuote(body))
+		bodyField = "  body,\n"
+	case len(s.Body) > 0:
+		bodyField = fmt.Sprintf("  body: %s,\n", jsonQuote(body))
+	}
+
+	fmt.Fprintf(&b, "const response = await fetch(%s, {\n  method: %s,\n  headers: {\n", jsonQuote(s.URL), jsonQuote(s.Method))
+	for _, h := range s.Headers {
+		fmt.Fprintf(&b, "    %s: %s,\n", jsonQuote(h[0]), jsonQuote(h[1]))
+	}
+	b.WriteString("  },\n" + bodyField + "});\n")
+	b.WriteString("console.log(response.status);\nconsole.log(await response.text());\n")
+	return b.String()
+}
+
+// Stellt die Bestandteile einer gespeicherten Anfrage für die Snippets zusammen.
+// Mit target wird die Anfrage an einen anderen Host gerichtet, der Bin-Präfix entfällt dabei.
+func buildSnippetRequest(r Request, target string, inline bool) (snippetRequest, error) {
+	s := snippetRequest{Method: r.Method, URL: config.BaseURL + r.URL}
+
+	if target != "" {
+		t, err := url.Parse(target)
+		if err != nil || (t.Scheme != "http" && t.Scheme != "https") || t.Host == "" {
+			return s, fmt.Errorf("ungültiges Ziel %q", target)
+		}
+		u, err := url.Parse(r.URL)
+		if err != nil {
+			return s, fmt.Errorf("ungültige URL %q", r.URL)
+		}
+		t.Path = strings.TrimSuffix(t.Path, "/") + pathInBin(u.Path)
+		t.RawQuery = u.RawQuery
+		s.URL = t.String()
+	}
+
+	for name, values := range r.Headers {
+		if snippetSkippedHeaders[name] {
+			continue
+		}
+		for _, v := range values {
+			s.Headers = append(s.Headers, [2]string{name, v})
+		}
+	}
+	sort.SliceStable(s.Headers, func(i, j int) bool {
+		return s.Headers[i][0] < s.Headers[j][0]
+	})
+
+	switch {
+	case len(r.BodyParams) > 0:
+		form := url.Values{}
+		for key, value := range r.BodyParams {
+			form.Set(key, value)
+		}
+		s.Body = []byte(form.Encode())
+	case r.LinkToFile != "" && !inline:
+		s.BodyURL = r.LinkToFile
+	default:
+		body, err := readRequestBody(r)
+		if err != nil {
+			log.Println("Fehler beim Lesen des Request-Body:", err)
+		}
+		s.Body = body
+	}
+	return s, nil
+}
+
+// Gibt eine gespeicherte Anfrage als ausführbares Snippet aus.
+// Query-Parameter: lang (curl, httpie, go, python, fetch), body (inline oder file) und target (anderer Host).
+func viewRequestSnippet(c *gin.Context) {
+	req, ok := findRequest(c.Param("id"))
+	if !ok {
+		c.String(http.StatusNotFound, "Anfrage nicht gefunden")
+		return
+	}
+	if !authorizeBin(c, req.Bin, false) {
+		return
+	}
+
+	generate, ok := snippetGenerators[c.DefaultQuery("lang", "curl")]
+	if !ok {
+		c.String(http.StatusBadRequest, "Unbekannte Sprache, erlaubt sind curl, httpie, go, python und fetch")
+		return
+	}
+	mode := c.DefaultQuery("body", "inline")
+	if mode != "inline" && mode != "file" {
+		c.String(http.StatusBadRequest, "Ungültiger Wert für body, erlaubt sind inline und file")
+		return
+	}
+
+	s, err := buildSnippetRequest(req, c.Query("target"), mode == "inline")
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+	c.String(http.StatusOK, generate(s))
+}
@@ -0,0 +1,20 @@
+package inspector
+
+import "testing"
+
+func TestShellSnippetsQuoteMethod(t *testing.T) {
+	s := snippetRequest{Method: "GET'; touch x; '", URL: "http://example.com/"}
+	tests := []struct {
+		name string
+		gen  snippetGenerator
+		want string
+	}{
+		{"curl", curlSnippet, `curl -X 'GET'\''; touch x; '\''' 'http://example.com/'` + "\n"},
+		{"httpie", httpieSnippet, `http 'GET'\''; touch x; '\''' 'http://example.com/'` + "\n"},
+	}
+	for _, tt := range tests {
+		if got := tt.gen(s); got != tt.want {
+			t.Errorf("%s: %q, erwartet %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package inspector
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Höchstzahl der Zeitabschnitte im Histogramm, damit ein kleines Intervall über einen großen Zeitraum
+// nicht beliebig viel Speicher belegt
+const maxStatsBuckets = 10000
+
+// Liefert für eine Anfrage den Wert, nach dem gruppiert wird
+var statsDimensions = map[string]func(r Request) string{
+	"path": func(r Request) string {
+		if u, err := url.Parse(r.URL); err == nil {
+			return pathInBin(u.Path)
+		}
+		return r.URL
+	},
+	"method": func(r Request) string { return r.Method },
+	"status": func(r Request) string {
+		// Ältere Anfragen wurden ohne Statuscode gespeichert
+		if r.Status == 0 {
+			return "unbekannt"
+		}
+		return strconv.Itoa(r.Status)
+	},
+	"content_type": func(r Request) string { return r.ContentType },
+	"remote_addr":  func(r Request) string { return r.RemoteAddr },
+	"user_agent":   func(r Request) string { return r.UserAgent },
+}
+
+// Anzahl der Anfragen mit einem bestimmten Wert
+type StatsCount struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// Ein Zeitabschnitt des Histogramms
+type StatsBucket struct {
+	Start time.Time `json:"start"`
+	Count int       `json:"count"`
+}
+
+// Verteilung der Body-Größen in Bytes
+type SizeStats struct {
+	Min  int     `json:"min"`
+	Max  int     `json:"max"`
+	Mean float64 `json:"mean"`
+	P50  int     `json:"p50"`
+	P90  int     `json:"p90"`
+	P95  int     `json:"p95"`
+	P99  int     `json:"p99"`
+}
+
+// Die Auswertung der Anfragen eines Bins im gewählten Zeitraum
+type Stats struct {
+	Bin       string                  `json:"bin"`
+	From      *time.Time              `json:"from,omitempty"`
+	To        *time.Time              `json:"to,omitempty"`
+	Total     int                     `json:"total"`
+	Groups    map[string][]StatsCount `json:"groups"`
+	Interval  string                  `json:"interval"`
+	Histogram []StatsBucket           `json:"histogram"`
+	Sizes     SizeStats               `json:"sizes"`
+}
+
+// Liest den Zeitraum aus den Query-Parametern: from und to als RFC 3339 oder since als Dauer ("1h")
+func parseStatsRange(c *gin.Context) (from, to *time.Time, err error) {
+	if since := c.Query("since"); since != "" {
+		d, err := time.ParseDuration(since)
+		if err != nil || d <= 0 {
+			return nil, nil, fmt.Errorf("ungültige Dauer für since")
+		}
+		t := time.Now().Add(-d)
+		from = &t
+	}
+	if value := c.Query("from"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("from muss im Format RFC 3339 angegeben werden")
+		}
+		from = &t
+	}
+	if value := c.Query("to"); value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("to muss im Format RFC 3339 angegeben werden")
+		}
+		to = &t
+	}
+	return from, to, nil
+}
+
+// Zählt die Anfragen je Wert, häufigste zuerst. Mit top > 0 werden nur die ersten Einträge geliefert.
+func countBy(list []Request, value func(r Request) string, top int) []StatsCount {
+	counts := make(map[string]int)
+	for _, r := range list {
+		counts[value(r)]++
+	}
+
+	result := make([]StatsCount, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, StatsCount{Key: key, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+	if top > 0 && len(result) > top {
+		result = result[:top]
+	}
+	return result
+}
+
+// Liefert den Beginn des ersten und des letzten Zeitabschnitts. Die Liste ist nicht verlässlich sortiert,
+// da eine Anfrage erst nach ihrer Antwort gespeichert wird, z. B. nach einem langsamen Upstream.
+func histogramRange(list []Request, interval time.Duration) (first, last time.Time) {
+	first, last = list[0].Timestamp, list[0].Timestamp
+	for _, r := range list[1:] {
+		if r.Timestamp.Before(first) {
+			first = r.Timestamp
+		}
+		if r.Timestamp.After(last) {
+			last = r.Timestamp
+		}
+	}
+	return first.Truncate(interval), last.Truncate(interval)
+}
+
+// Anzahl der Zeitabschnitte im Histogramm
+func histogramSize(list []Request, interval time.Duration) int {
+	if len(list) == 0 {
+		return 0
+	}
+	first, last := histogramRange(list, interval)
+	return int(last.Sub(first)/interval) + 1
+}
+
+// Teilt die Anfragen in gleich lange Zeitabschnitte ein. Leere Abschnitte sind enthalten.
+func histogram(list []Request, interval time.Duration) []StatsBucket {
+	if len(list) == 0 {
+		return []StatsBucket{}
+	}
+
+	first, last := histogramRange(list, interval)
+	buckets := make([]StatsBucket, int(last.Sub(first)/interval)+1)
+	for i := range buckets {
+		buckets[i].Start = first.Add(time.Duration(i) * interval)
+	}
+	for _, r := range list {
+		buckets[int(r.Timestamp.Truncate(interval).Sub(first)/interval)].Count++
+	}
+	return buckets
+}
+
+// Berechnet die Perzentile der Body-Größen nach dem Nearest-Rank-Verfahren
+func sizeStats(list []Request) SizeStats {
+	if len(list) == 0 {
+		return SizeStats{}
+	}
+
+	sizes := make([]int, len(list))
+	sum := 0
+	for i, r := range list {
+		sizes[i] = r.BodySize
+		sum += r.BodySize
+	}
+	sort.Ints(sizes)
+
+	percentile := func(p float64) int {
+		rank := int(math.Ceil(p / 100 * float64(len(sizes))))
+		if rank < 1 {
+			rank = 1
+		}
+		return sizes[rank-1]
+	}
+	return SizeStats{
+		Min:  sizes[0],
+		Max:  sizes[len(sizes)-1],
+		Mean: float64(sum) / float64(len(sizes)),
+		P50:  percentile(50),
+		P90:  percentile(90),
+		P95:  percentile(95),
+		P99:  percentile(99),
+	}
+}
+
+// Gibt Statistiken über die Anfragen eines Bins aus.
+// Query-Parameter: group_by (kommagetrennt, Standard: alle), top, interval (Standard "1h"),
+// from/to bzw. since für den Zeitraum sowie die Filter aus /view-requests.
+func viewStats(c *gin.Context) {
+	bin, ok := binFromQuery(c)
+	if !ok {
+		return
+	}
+	filter, err := parseRequestFilter(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Ungültiger Filter: "+err.Error())
+		return
+	}
+	from, to, err := parseStatsRange(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dimensions := make([]string, 0, len(statsDimensions))
+	if value := c.Query("group_by"); value != "" {
+		for _, d := range strings.Split(value, ",") {
+			if _, ok := statsDimensions[d]; !ok {
+				c.String(http.StatusBadRequest, fmt.Sprintf("Unbekannte Gruppierung %q", d))
+				return
+			}
+			dimensions = append(dimensions, d)
+		}
+	} else {
+		for d := range statsDimensions {
+			dimensions = append(dimensions, d)
+		}
+	}
+
+	top := 0
+	if value := c.Query("top"); value != "" {
+		if top, err = strconv.Atoi(value); err != nil || top < 0 {
+			c.String(http.StatusBadRequest, "Ungültiger Wert für top")
+			return
+		}
+	}
+
+	interval, err := time.ParseDuration(c.DefaultQuery("interval", "1h"))
+	if err != nil || interval <= 0 {
+		c.String(http.StatusBadRequest, "Ungültiges Intervall")
+		return
+	}
+
+	list := []Request{}
+	for _, r := range filterRequests(requestsInBin(bin), filter) {
+		if from != nil && r.Timestamp.Before(*from) {
+			continue
+		}
+		if to != nil && !r.Timestamp.Before(*to) {
+			continue
+		}
+		list = append(list, r)
+	}
+	if histogramSize(list, interval) > maxStatsBuckets {
+		c.String(http.StatusBadRequest, "Intervall zu klein für den Zeitraum")
+		return
+	}
+
+	stats := Stats{
+		Bin:       bin,
+		From:      from,
+		To:        to,
+		Total:     len(list),
+		Groups:    make(map[string][]StatsCount, len(dimensions)),
+		Interval:  interval.String(),
+		Histogram: histogram(list, interval),
+		Sizes:     sizeStats(list),
+	}
+	for _, d := range dimensions {
+		stats.Groups[d] = countBy(list, statsDimensions[d], top)
+	}
+	c.JSON(http.StatusOK, stats)
+}
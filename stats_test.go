@@ -0,0 +1,50 @@
+package inspector
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistogram(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds ...int) []Request {
+		list := make([]Request, len(seconds))
+		for i, s := range seconds {
+			list[i] = Request{Timestamp: base.Add(time.Duration(s) * time.Second)}
+		}
+		return list
+	}
+
+	tests := []struct {
+		name     string
+		list     []Request
+		interval time.Duration
+		want     []int // Anzahl je Abschnitt ab dem ersten
+	}{
+		{"leer", nil, time.Second, []int{}},
+		{"neueste zuerst", at(3, 1, 0), time.Second, []int{1, 1, 0, 1}},
+		// Eine Anfrage mit langsamem Upstream wird erst nach einer späteren gespeichert
+		{"nicht sortiert", at(0, 2), time.Second, []int{1, 0, 1}},
+		{"nicht sortiert in der Mitte", at(5, 0, 9, 2), 5 * time.Second, []int{2, 2}},
+		{"ein Abschnitt", at(1, 0, 1), time.Minute, []int{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets := histogram(tt.list, tt.interval)
+			counts := []int{}
+			for i, b := range buckets {
+				counts = append(counts, b.Count)
+				if want := base.Add(time.Duration(i) * tt.interval); !b.Start.Equal(want) {
+					t.Errorf("Abschnitt %d beginnt %v, erwartet %v", i, b.Start, want)
+				}
+			}
+			if !reflect.DeepEqual(counts, tt.want) {
+				t.Errorf("Anzahlen %v, erwartet %v", counts, tt.want)
+			}
+			if n := histogramSize(tt.list, tt.interval); n != len(tt.want) {
+				t.Errorf("histogramSize %d, erwartet %d", n, len(tt.want))
+			}
+		})
+	}
+}
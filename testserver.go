@@ -0,0 +1,222 @@
+package inspector
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Der Teil von testing.TB, den Server verwendet. *testing.T und *testing.B erfüllen ihn,
+// das Paket selbst importiert "testing" nicht.
+type TB interface {
+	Helper()
+	Fatal(args ...interface{})
+	Cleanup(func())
+}
+
+// Ein Inspector für Go-Tests, wie httptest.Server.
+// Capture-Server und Management-API laufen auf zufälligen Ports, gespeichert wird in einem temporären Verzeichnis.
+type Server struct {
+	CaptureURL    string // Basis-URL des Capture-Servers, z. B. "http://127.0.0.1:53121"
+	ManagementURL string // Basis-URL der Management-API
+	AdminToken    string // Admin-Token für die Management-API
+	DataDir       string // das temporäre Datenverzeichnis
+
+	t          TB
+	capture    *httptest.Server
+	management *httptest.Server
+}
+
+// Der Zustand liegt in Variablen des Pakets, daher darf immer nur ein Server laufen.
+// Parallele Tests (t.Parallel) mit je einem Server warten aufeinander.
+var (
+	testServerMu    sync.Mutex
+	testServerFree  = sync.NewCond(&testServerMu)
+	testServerOwner TB
+)
+
+// Startet einen Inspector mit leerem Speicher. Er wird mit t.Cleanup beendet und sein Verzeichnis entfernt.
+// Konfigurationsdatei und Umgebungsvariablen gelten wie beim Programm, ausgenommen Verzeichnisse und Adressen.
+// Läuft bereits ein Server, wartet NewServer, bis dieser beendet ist. Ein Untertest darf daher keinen
+// Server starten, solange sein übergeordneter Test einen hat.
+func NewServer(t TB) *Server {
+	t.Helper()
+	testServerMu.Lock()
+	if testServerOwner == t {
+		testServerMu.Unlock()
+		t.Fatal("inspector: dieser Test hat bereits einen Server")
+	}
+	for testServerOwner != nil {
+		testServerFree.Wait()
+	}
+	testServerOwner = t
+	testServerMu.Unlock()
+	t.Cleanup(func() {
+		testServerMu.Lock()
+		testServerOwner = nil
+		testServerMu.Unlock()
+		testServerFree.Signal()
+	})
+
+	cfg, err := LoadConfig(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := os.MkdirTemp("", "inspector-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	defaults := defaultConfig()
+	cfg.DataDir, cfg.RequestsDir, cfg.StaticFilesDir = dir, defaults.RequestsDir, defaults.StaticFilesDir
+
+	resetState()
+	config = cfg
+	secret := generateTokenSecret()
+	tokens[hashToken(secret)] = Token{ID: uuid.New().String(), Name: "test", Role: roleAdmin, Hash: hashToken(secret), CreatedAt: time.Now()}
+	if err := Setup(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{AdminToken: secret, DataDir: dir, t: t}
+	s.capture = httptest.NewServer(NewCaptureRouter())
+	s.management = httptest.NewServer(NewManagementRouter())
+	s.CaptureURL = s.capture.URL
+	s.ManagementURL = s.management.URL
+	config.BaseURL = s.CaptureURL
+
+	t.Cleanup(func() {
+		// Offene SSE-Verbindungen würden Close sonst blockieren
+		s.management.CloseClientConnections()
+		s.management.Close()
+		s.capture.CloseClientConnections()
+		s.capture.Close()
+	})
+	return s
+}
+
+// Setzt alle Variablen des Pakets auf den Zustand beim Programmstart zurück
+func resetState() {
+	requestsMu.Lock()
+	requests = nil
+	requestsMu.Unlock()
+
+	binsMu.Lock()
+	bins = make(map[string]Bin)
+	binsMu.Unlock()
+
+	tokensMu.Lock()
+	tokens = make(map[string]Token)
+	tokensMu.Unlock()
+
+	schemaRulesMu.Lock()
+	schemaRules = make(map[string]SchemaRule)
+	schemaRulesMu.Unlock()
+
+	openAPISpecsMu.Lock()
+	openAPISpecs = make(map[string]map[string]interface{})
+	openAPISpecsMu.Unlock()
+
+	mockRulesMu.Lock()
+	mockRules = make(map[string]MockRule)
+	mockRulesMu.Unlock()
+
+	scenarioStatesMu.Lock()
+	scenarioStates = make(map[string]map[string]string)
+	scenarioStatesMu.Unlock()
+
+	eventLogMu.Lock()
+	eventSeq = 0
+	eventSeqReserved = 0
+	eventLog = nil
+	eventLogMu.Unlock()
+
+	eventSeqFileMu.Lock()
+	eventSeqWritten = 0
+	eventSeqFileMu.Unlock()
+
+	SSEClientsMu.Lock()
+	SSEClients = make(map[string]SSEClient)
+	SSEClientsMu.Unlock()
+
+	metricsMu.Lock()
+	capturedRequests = make(map[string]uint64)
+	capturedBodyBytes = make(map[string]uint64)
+	retentionEvictions = make(map[string]uint64)
+	sseDroppedMessages = make(map[string]uint64)
+	handlerLatency = make(map[string]*histogramMetric)
+	metricsMu.Unlock()
+
+	config = defaultConfig().withBaseURL()
+}
+
+// Liefert die URL des Capture-Servers für einen Pfad in einem Bin, z. B. URL("orders", "/webhook")
+func (s *Server) URL(bin, path string) string {
+	return s.CaptureURL + "/b/" + bin + "/" + strings.TrimPrefix(path, "/")
+}
+
+// Legt einen Bin an, falls er noch nicht existiert
+func (s *Server) CreateBin(name string) {
+	s.t.Helper()
+	if !binNamePattern.MatchString(name) {
+		s.t.Fatal(fmt.Sprintf("ungültiger Bin-Name %q", name))
+	}
+	binsMu.Lock()
+	defer binsMu.Unlock()
+	if _, ok := bins[name]; ok {
+		return
+	}
+	b := Bin{Name: name, CreatedAt: time.Now()}
+	bins[name] = b
+	saveBinToFile(b)
+}
+
+// Liefert die gespeicherten Anfragen eines Bins, neueste zuerst
+func (s *Server) Requests(bin string) []Request {
+	return requestsInBin(bin)
+}
+
+// Liefert eine gespeicherte Anfrage anhand ihrer ID
+func (s *Server) Request(id string) (Request, bool) {
+	return findRequest(id)
+}
+
+// Liest den gespeicherten Body einer Anfrage
+func (s *Server) Body(r Request) []byte {
+	s.t.Helper()
+	body, err := readRequestBody(r)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return body
+}
+
+// Legt eine Mock-Regel an und liefert sie mit ID zurück. Ohne Bin gilt sie für den Standard-Bin.
+func (s *Server) AddMockRule(rule MockRule) MockRule {
+	s.t.Helper()
+	if rule.Bin == "" {
+		rule.Bin = defaultBin
+	}
+	if !binExists(rule.Bin) {
+		s.t.Fatal(fmt.Sprintf("Bin %q existiert nicht", rule.Bin))
+	}
+	if err := rule.validate(); err != nil {
+		s.t.Fatal(err)
+	}
+	return addMockRule(rule)
+}
+
+// Liefert die Mock-Regeln eines Bins
+func (s *Server) MockRules(bin string) []MockRule {
+	return mockRulesInBin(bin)
+}
+
+// Entfernt eine Mock-Regel
+func (s *Server) RemoveMockRule(id string) {
+	removeMockRule(id)
+}
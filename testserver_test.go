@@ -0,0 +1,74 @@
+package inspector
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewServerStoresInDataDir(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var dirs []string
+	for _, name := range []string{"erster", "zweiter"} {
+		t.Run(name, func(t *testing.T) {
+			s := NewServer(t)
+			dirs = append(dirs, s.DataDir)
+
+			resp, err := http.Post(s.URL(defaultBin, "/hook"), "application/json", strings.NewReader(`{"a":1}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			list := s.Requests(defaultBin)
+			if len(list) != 1 {
+				t.Fatalf("%d Anfragen gespeichert, erwartet 1", len(list))
+			}
+			if _, err := os.Stat(filepath.Join(s.DataDir, "requests", list[0].ID+".json")); err != nil {
+				t.Errorf("Anfrage nicht im Datenverzeichnis gespeichert: %v", err)
+			}
+			if string(s.Body(list[0])) != `{"a":1}` {
+				t.Errorf("Body %q", s.Body(list[0]))
+			}
+			if now, _ := os.Getwd(); now != wd {
+				t.Errorf("Arbeitsverzeichnis gewechselt nach %s", now)
+			}
+		})
+	}
+
+	if len(dirs) != 2 || dirs[0] == dirs[1] {
+		t.Fatalf("Datenverzeichnisse %v, erwartet zwei verschiedene", dirs)
+	}
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("%s wurde nicht entfernt", dir)
+		}
+	}
+}
+
+// Parallele Tests mit je einem Server laufen nacheinander und sehen nur ihre eigenen Anfragen
+func TestNewServerParallel(t *testing.T) {
+	for _, name := range []string{"a", "b", "c"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			s := NewServer(t)
+			resp, err := http.Post(s.URL(defaultBin, "/"+name), "text/plain", strings.NewReader(name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			list := s.Requests(defaultBin)
+			if len(list) != 1 || string(s.Body(list[0])) != name {
+				t.Errorf("%d Anfragen, erwartet nur die eigene", len(list))
+			}
+		})
+	}
+}
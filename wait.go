@@ -0,0 +1,145 @@
+package inspector
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Wartezeit von /requests/wait ohne Angabe und die längste erlaubte
+const (
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitTimeout     = 5 * time.Minute
+)
+
+// Die Antwort von /requests/wait. Cursor ist die Nummer des letzten gelieferten Ereignisses
+// und wird beim nächsten Aufruf übergeben, um an dieser Stelle fortzufahren.
+type WaitResult struct {
+	Requests []Request `json:"requests"`
+	Cursor   uint64    `json:"cursor"`
+}
+
+// Wartet, bis passende Anfragen aufgezeichnet wurden, und liefert sie als WaitResult.
+// Query-Parameter: bin, die Filter aus /view-requests (einzeln oder zusammen als filter=... kodiert),
+// timeout (Standard "30s"), count (Anzahl der erwarteten Anfragen, Standard 1) und cursor.
+// Mit cursor zählen auch passende Anfragen, die nach diesem Ereignis bereits eingetroffen sind,
+// ohne cursor nur die ab dem Aufruf eintreffenden.
+// Läuft die Zeit ab, antwortet der Server mit 408 und den bis dahin gefundenen Anfragen.
+// Treffen mehr Ereignisse ein, als die Warteschlange fasst, antwortet er ebenso mit 503, der Aufrufer wartet dann
+// mit dem gelieferten Cursor erneut.
+func waitForRequests(c *gin.Context) {
+	bin, ok := binFromQuery(c)
+	if !ok {
+		return
+	}
+
+	query := c.Request.URL.Query()
+	if encoded := query.Get("filter"); encoded != "" {
+		extra, err := url.ParseQuery(encoded)
+		if err != nil {
+			c.String(http.StatusBadRequest, "Ungültiger Filter")
+			return
+		}
+		for key, values := range extra {
+			query[key] = append(query[key], values...)
+		}
+	}
+	filter, err := parseFilterValues(query)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Ungültiger Filter: "+err.Error())
+		return
+	}
+
+	timeout := defaultWaitTimeout
+	if value := c.Query("timeout"); value != "" {
+		timeout, err = time.ParseDuration(value)
+		if err != nil || timeout <= 0 || timeout > maxWaitTimeout {
+			c.String(http.StatusBadRequest, fmt.Sprintf("timeout muss eine Dauer bis %s sein", maxWaitTimeout))
+			return
+		}
+	}
+
+	count := 1
+	if value := c.Query("count"); value != "" {
+		if count, err = strconv.Atoi(value); err != nil || count < 1 {
+			c.String(http.StatusBadRequest, "count muss eine positive Zahl sein")
+			return
+		}
+	}
+
+	var cursor uint64
+	resume := false
+	if value := c.Query("cursor"); value != "" {
+		if cursor, err = strconv.ParseUint(value, 10, 64); err != nil {
+			c.String(http.StatusBadRequest, "Ungültiger Cursor")
+			return
+		}
+		resume = true
+	}
+
+	// Wie ein SSE-Client anmelden. Bereits eingetroffene Anfragen und die Anmeldung unter derselben Sperre,
+	// damit keine Anfrage zwischen beiden verloren geht. Immer mit "disconnect": verworfene Nachrichten
+	// lägen sonst vor dem gelieferten Cursor und fehlten auch beim nächsten Aufruf.
+	queue := newSSEQueue(config.SSEQueueSize, overflowDisconnect)
+	clientId := generateRandomString(50)
+	var missed []sseEvent
+	eventLogMu.Lock()
+	if resume {
+		missed = missedEvents(bin, cursor, filter)
+	} else {
+		cursor = eventSeq
+	}
+	SSEClientsMu.Lock()
+	SSEClients[clientId] = SSEClient{bin: bin, filter: filter, queue: queue}
+	SSEClientsMu.Unlock()
+	eventLogMu.Unlock()
+
+	defer func() {
+		SSEClientsMu.Lock()
+		delete(SSEClients, clientId)
+		SSEClientsMu.Unlock()
+	}()
+
+	result := WaitResult{Requests: []Request{}, Cursor: cursor}
+	collect := func(events []sseEvent) bool {
+		for _, e := range events {
+			if e.Name != eventRequestCreated || e.Request == nil {
+				continue
+			}
+			result.Requests = append(result.Requests, *e.Request)
+			result.Cursor = e.ID
+			if len(result.Requests) == count {
+				return true
+			}
+		}
+		return false
+	}
+	if collect(missed) {
+		c.JSON(http.StatusOK, result)
+		return
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-queue.notify:
+			if collect(queue.drain()) {
+				c.JSON(http.StatusOK, result)
+				return
+			}
+		case <-queue.kicked:
+			c.JSON(http.StatusServiceUnavailable, result)
+			return
+		case <-timer.C:
+			c.JSON(http.StatusRequestTimeout, result)
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Ruft /requests/wait auf und liefert Status und Ergebnis
+func waitRequest(t *testing.T, s *Server, query string) (int, WaitResult) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, s.ManagementURL+"/requests/wait?bin="+defaultBin+"&"+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+s.AdminToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var result WaitResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		t.Fatalf("Status %d, Antwort kein WaitResult: %v", resp.StatusCode, err)
+	}
+	return resp.StatusCode, result
+}
+
+// Sendet Anfragen an den Capture-Server, ihr Pfad ist jeweils /n
+func capture(t *testing.T, s *Server, from, to int) {
+	t.Helper()
+	for i := from; i <= to; i++ {
+		resp, err := http.Post(s.URL(defaultBin, fmt.Sprint("/", i)), "text/plain", strings.NewReader("x"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+}
+
+// Wartet, bis sich ein Aufruf von /requests/wait angemeldet hat, und liefert seine Warteschlange
+func registeredWaiter(t *testing.T) *sseQueue {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		SSEClientsMu.Lock()
+		for _, client := range SSEClients {
+			SSEClientsMu.Unlock()
+			return client.queue
+		}
+		SSEClientsMu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("Aufruf hat sich nicht angemeldet")
+	return nil
+}
+
+func paths(list []Request) []string {
+	result := []string{}
+	for _, r := range list {
+		result = append(result, strings.TrimPrefix(r.URL, "/b/"+defaultBin))
+	}
+	return result
+}
+
+func TestWaitForRequests(t *testing.T) {
+	s := NewServer(t)
+
+	// Ohne Cursor zählen nur Anfragen ab dem Aufruf
+	capture(t, s, 0, 0)
+	done := make(chan WaitResult)
+	go func() {
+		status, result := waitRequest(t, s, "count=2&timeout=5s")
+		if status != http.StatusOK {
+			t.Errorf("Status %d, erwartet 200", status)
+		}
+		done <- result
+	}()
+	registeredWaiter(t)
+	capture(t, s, 1, 3)
+	first := <-done
+	if got := strings.Join(paths(first.Requests), ","); got != "/1,/2" {
+		t.Fatalf("Anfragen %s, erwartet /1,/2", got)
+	}
+	if first.Cursor != first.Requests[1].Seq {
+		t.Errorf("Cursor %d, erwartet %d", first.Cursor, first.Requests[1].Seq)
+	}
+
+	// Mit Cursor zählen auch bereits eingetroffene Anfragen
+	status, next := waitRequest(t, s, fmt.Sprintf("count=1&cursor=%d", first.Cursor))
+	if status != http.StatusOK || strings.Join(paths(next.Requests), ",") != "/3" {
+		t.Errorf("Status %d, Anfragen %v, erwartet /3", status, paths(next.Requests))
+	}
+
+	// Nach Ablauf der Zeit die bis dahin gefundenen Anfragen
+	status, rest := waitRequest(t, s, fmt.Sprintf("count=2&timeout=50ms&cursor=%d", first.Cursor))
+	if status != http.StatusRequestTimeout || strings.Join(paths(rest.Requests), ",") != "/3" || rest.Cursor != next.Cursor {
+		t.Errorf("Status %d, Anfragen %v, Cursor %d", status, paths(rest.Requests), rest.Cursor)
+	}
+}
+
+// Bei zu vielen Ereignissen wird der Aufruf getrennt statt Anfragen zu verwerfen. Mit dem gelieferten Cursor
+// erhält der nächste Aufruf alle Anfragen.
+func TestWaitForRequestsOverflow(t *testing.T) {
+	s := NewServer(t)
+	config.SSEOverflowPolicy = overflowDropOldest
+
+	status := make(chan int)
+	results := make(chan WaitResult, 1)
+	go func() {
+		code, result := waitRequest(t, s, "count=10&timeout=5s")
+		results <- result
+		status <- code
+	}()
+	queue := registeredWaiter(t)
+	if queue.policy != overflowDisconnect {
+		t.Errorf("Überlaufverhalten %s, erwartet %s", queue.policy, overflowDisconnect)
+	}
+	capture(t, s, 1, 3)
+	// Der Überlauf selbst hängt davon ab, wie schnell der Aufruf liest, daher wird er hier ausgelöst
+	queue.kickOnce.Do(func() { close(queue.kicked) })
+	if code := <-status; code != http.StatusServiceUnavailable {
+		t.Fatalf("Status %d, erwartet 503", code)
+	}
+	partial := <-results
+
+	capture(t, s, 4, 4)
+	code, result := waitRequest(t, s, fmt.Sprintf("count=%d&timeout=1s&cursor=%d", 4-len(partial.Requests), partial.Cursor))
+	all := append(paths(partial.Requests), paths(result.Requests)...)
+	if code != http.StatusOK || strings.Join(all, ",") != "/1,/2,/3,/4" {
+		t.Errorf("Status %d, Anfragen %v, erwartet /1 bis /4", code, all)
+	}
+}
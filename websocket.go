@@ -0,0 +1,278 @@
+package inspector
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// Eine Nachricht vom Server an den Client über /ws.
+// "event" enthält ein Ereignis wie bei SSE (siehe events.go), "replay" eine auf Wunsch erneut gesendete Anfrage,
+// "ok" und "error" beantworten einen Befehl, "heartbeat" hält eine ruhende Verbindung offen.
+// "connected" ist die erste Nachricht und nennt in id die Nummer, ab der Ereignisse gesendet werden.
+type wsMessage struct {
+	Type    string          `json:"type"`
+	ID      uint64          `json:"id,omitempty"`    // Nummer des Ereignisses, wie das id-Feld bei SSE
+	Event   string          `json:"event,omitempty"` // Name des Ereignisses, z. B. "request.created"
+	Data    json.RawMessage `json:"data,omitempty"`
+	Command string          `json:"command,omitempty"` // bei "ok" und "error" der beantwortete Befehl
+	Error   string          `json:"error,omitempty"`
+}
+
+// Ein Befehl vom Client:
+//
+//	{"command": "filter", "filter": "method=POST&path=/orders/*"}  Filter ersetzen, Syntax wie bei /view-requests
+//	{"command": "pause"}                                           keine Ereignisse mehr senden
+//	{"command": "resume"}                                          verpasste Ereignisse nachholen und fortfahren
+//	{"command": "ack", "id": 42}                                   Ereignisse bis zur Nummer 42 sind verarbeitet
+//	{"command": "replay", "request_id": "..."}                     eine gespeicherte Anfrage erneut senden
+//
+// Nach einer Pause werden die Ereignisse nach dem zuletzt bestätigten Ereignis nachgeholt,
+// ohne Bestätigungen die nach dem zuletzt gesendeten.
+type wsCommand struct {
+	Command   string `json:"command"`
+	Filter    string `json:"filter,omitempty"`
+	ID        uint64 `json:"id,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Eine WebSocket-Verbindung. Sie wird wie ein SSE-Client in SSEClients geführt, solange sie nicht pausiert ist.
+// Alle Felder außer conn werden nur von der Schleife in serve verwendet.
+type wsSession struct {
+	conn     *websocket.Conn
+	id       string
+	bin      string
+	filter   requestFilter
+	queue    *sseQueue
+	paused   bool
+	lastSent uint64 // Nummer des zuletzt gesendeten Ereignisses
+	acked    uint64 // Nummer des zuletzt bestätigten Ereignisses
+}
+
+// Streamt dieselben Ereignisse wie /sse über eine WebSocket-Verbindung und nimmt Befehle des Clients an.
+// Query-Parameter wie bei /sse: bin, die Filter aus /view-requests und last_event_id für die Wiederaufnahme.
+func websocketHandler(c *gin.Context) {
+	bin, ok := binFromQuery(c)
+	if !ok {
+		return
+	}
+	filter, err := parseRequestFilter(c)
+	if err != nil {
+		c.String(http.StatusBadRequest, "Ungültiger Filter: "+err.Error())
+		return
+	}
+	lastID, resume, err := lastEventID(c.GetHeader("Last-Event-ID"), c.Query("last_event_id"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Ungültige Last-Event-ID")
+		return
+	}
+
+	server := websocket.Server{
+		// Angemeldet wird über das Token, der Origin wird wie bei der übrigen Management-API nicht geprüft
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			s := &wsSession{
+				conn:   conn,
+				id:     generateRandomString(50),
+				bin:    bin,
+				filter: filter,
+				queue:  newSSEQueue(config.SSEQueueSize, config.SSEOverflowPolicy),
+			}
+			s.serve(lastID, resume)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// Meldet die Verbindung für neue Ereignisse an und liefert die seit from verpassten.
+// Ohne replay beginnt die Verbindung beim aktuellen Ereignis.
+func (s *wsSession) subscribe(from uint64, replay bool) []sseEvent {
+	eventLogMu.Lock()
+	defer eventLogMu.Unlock()
+
+	var missed []sseEvent
+	if replay {
+		missed = missedEvents(s.bin, from, s.filter)
+	} else {
+		s.lastSent = eventSeq
+	}
+	SSEClientsMu.Lock()
+	SSEClients[s.id] = SSEClient{bin: s.bin, filter: s.filter, queue: s.queue}
+	SSEClientsMu.Unlock()
+	return missed
+}
+
+func (s *wsSession) unsubscribe() {
+	SSEClientsMu.Lock()
+	delete(SSEClients, s.id)
+	SSEClientsMu.Unlock()
+}
+
+// Sendet eine Nachricht. Ein hängender Client wird nach sseWriteTimeout getrennt.
+func (s *wsSession) send(m wsMessage) error {
+	s.conn.SetWriteDeadline(time.Now().Add(sseWriteTimeout))
+	return websocket.JSON.Send(s.conn, m)
+}
+
+// Sendet Ereignisse und merkt sich das zuletzt gesendete
+func (s *wsSession) sendEvents(events []sseEvent) error {
+	for _, e := range events {
+		if err := s.send(wsMessage{Type: "event", ID: e.ID, Event: e.Name, Data: e.Data}); err != nil {
+			return err
+		}
+		s.lastSent = e.ID
+	}
+	return nil
+}
+
+func (s *wsSession) serve(lastID uint64, resume bool) {
+	fmt.Println("WebSocket-Client verbunden:", s.id)
+	missed := s.subscribe(lastID, resume)
+	start := s.lastSent
+	if resume {
+		start = lastID
+	}
+	defer func() {
+		s.unsubscribe()
+		s.conn.Close()
+		fmt.Println("WebSocket-Client getrennt:", s.id, "verworfene Nachrichten:", s.queue.droppedCount())
+	}()
+
+	// Befehle werden in einer eigenen Goroutine gelesen, geschrieben wird nur in der Schleife unten
+	commands := make(chan wsCommand)
+	readDone := make(chan struct{})
+	handlerDone := make(chan struct{})
+	defer close(handlerDone)
+	go func() {
+		defer close(readDone)
+		for {
+			var raw string
+			if err := websocket.Message.Receive(s.conn, &raw); err != nil {
+				return
+			}
+			// Ein ungültiger Befehl wird mit leerem Namen weitergereicht und als Fehler beantwortet
+			var cmd wsCommand
+			json.Unmarshal([]byte(raw), &cmd)
+			select {
+			case commands <- cmd:
+			case <-handlerDone:
+				return
+			}
+		}
+	}()
+
+	// Soll der Client wegen einer vollen Warteschlange getrennt werden, bricht ein laufender Schreibvorgang sofort ab
+	go func() {
+		select {
+		case <-s.queue.kicked:
+			s.conn.Close()
+		case <-handlerDone:
+		}
+	}()
+
+	if err := s.send(wsMessage{Type: "connected", ID: start}); err != nil {
+		return
+	}
+	if err := s.sendEvents(missed); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-heartbeat.C:
+			if err := s.send(wsMessage{Type: "heartbeat"}); err != nil {
+				return
+			}
+		case <-s.queue.notify:
+			if err := s.sendEvents(s.queue.drain()); err != nil {
+				return
+			}
+		case cmd := <-commands:
+			if err := s.handleCommand(cmd); err != nil {
+				return
+			}
+		case <-s.queue.kicked:
+			fmt.Println("Client zu langsam, Verbindung wird getrennt:", s.id)
+			return
+		case <-readDone:
+			return
+		}
+	}
+}
+
+// Führt einen Befehl aus und beantwortet ihn. Ein Fehler wird nur bei einem gescheiterten Schreibvorgang geliefert.
+func (s *wsSession) handleCommand(cmd wsCommand) error {
+	fail := func(msg string) error {
+		return s.send(wsMessage{Type: "error", Command: cmd.Command, Error: msg})
+	}
+
+	switch cmd.Command {
+	case "filter":
+		query, err := url.ParseQuery(cmd.Filter)
+		if err != nil {
+			return fail("Ungültiger Filter")
+		}
+		filter, err := parseFilterValues(query)
+		if err != nil {
+			return fail("Ungültiger Filter: " + err.Error())
+		}
+		s.filter = filter
+		if !s.paused {
+			SSEClientsMu.Lock()
+			SSEClients[s.id] = SSEClient{bin: s.bin, filter: s.filter, queue: s.queue}
+			SSEClientsMu.Unlock()
+		}
+
+	case "pause":
+		if !s.paused {
+			// Noch nicht gesendete Ereignisse werden beim Fortsetzen nachgeholt
+			s.unsubscribe()
+			s.queue.drain()
+			s.paused = true
+		}
+
+	case "resume":
+		if s.paused {
+			from := s.lastSent
+			if s.acked > 0 {
+				from = s.acked
+			}
+			s.paused = false
+			if err := s.send(wsMessage{Type: "ok", Command: cmd.Command}); err != nil {
+				return err
+			}
+			return s.sendEvents(s.subscribe(from, true))
+		}
+
+	case "ack":
+		if cmd.ID > s.lastSent {
+			return fail("Ereignis wurde noch nicht gesendet")
+		}
+		if cmd.ID > s.acked {
+			s.acked = cmd.ID
+		}
+
+	case "replay":
+		req, ok := findRequest(cmd.RequestID)
+		if !ok || req.Bin != s.bin {
+			return fail("Anfrage nicht gefunden")
+		}
+		data, err := json.Marshal(req)
+		if err != nil {
+			return fail("Anfrage kann nicht gesendet werden")
+		}
+		return s.send(wsMessage{Type: "replay", ID: req.Seq, Event: eventRequestCreated, Data: data})
+
+	default:
+		return fail("Unbekannter Befehl")
+	}
+	return s.send(wsMessage{Type: "ok", Command: cmd.Command})
+}